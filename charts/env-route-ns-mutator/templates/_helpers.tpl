@@ -60,3 +60,88 @@ Create the name of the service account to use
 {{- default "default" .Values.serviceAccount.name }}
 {{- end }}
 {{- end }}
+
+{{/*
+Render a list of label requirements as a label selector string
+*/}}
+{{- define "env-route-ns-mutator.requirements" -}}
+{{- $requirements := list }}
+{{- range . }}
+{{- if eq .operator "In" }}
+{{- $requirements = append $requirements (printf "%s in (%s)" .key (join "," .values)) }}
+{{- else if eq .operator "NotIn" }}
+{{- $requirements = append $requirements (printf "%s notin (%s)" .key (join "," .values)) }}
+{{- else if eq .operator "Exists" }}
+{{- $requirements = append $requirements .key }}
+{{- else if eq .operator "DoesNotExist" }}
+{{- $requirements = append $requirements (printf "!%s" .key) }}
+{{- end }}
+{{- end }}
+{{- join "," $requirements }}
+{{- end }}
+
+{{/*
+Render label requirements as inverted match expressions, so that objects matching
+any of the requirements are never sent to the webhook
+*/}}
+{{- define "env-route-ns-mutator.invertedExpressions" -}}
+{{- $inverse := dict "In" "NotIn" "NotIn" "In" "Exists" "DoesNotExist" "DoesNotExist" "Exists" }}
+{{- range . }}
+- key: {{ .key }}
+  operator: {{ get $inverse .operator }}
+  {{- if .values }}
+  values:
+  {{- toYaml .values | nindent 2 }}
+  {{- end }}
+{{- end }}
+{{- end }}
+
+{{/*
+Webhook namespaceSelector and objectSelector matching the configured exclusions
+*/}}
+{{- define "env-route-ns-mutator.webhookSelectors" -}}
+{{- $exclusions := .Values.config.exclusions }}
+{{- $names := list }}
+{{- range $exclusions.namespaces }}
+{{- if not (regexMatch "[*?\\[]" .) }}
+{{- $names = append $names . }}
+{{- end }}
+{{- end }}
+{{- if or $names $exclusions.namespaceLabels }}
+namespaceSelector:
+  matchExpressions:
+  {{- if $names }}
+  - key: kubernetes.io/metadata.name
+    operator: NotIn
+    values:
+    {{- toYaml $names | nindent 4 }}
+  {{- end }}
+  {{- include "env-route-ns-mutator.invertedExpressions" $exclusions.namespaceLabels | nindent 2 }}
+{{- end }}
+{{- if $exclusions.objectLabels }}
+objectSelector:
+  matchExpressions:
+  {{- include "env-route-ns-mutator.invertedExpressions" $exclusions.objectLabels | nindent 2 }}
+{{- end }}
+{{- end }}
+
+{{/*
+Webhook namespaceSelector leaving out the system namespaces by name, which unlike labels
+cannot be forged: the release namespace, the exact names of the excluded namespaces and
+the systemNamespaces
+*/}}
+{{- define "env-route-ns-mutator.systemNamespaceSelector" -}}
+{{- $names := list .Release.Namespace }}
+{{- range .Values.config.exclusions.namespaces }}
+{{- if not (regexMatch "[*?\\[]" .) }}
+{{- $names = append $names . }}
+{{- end }}
+{{- end }}
+{{- $names = concat $names .Values.systemNamespaces | uniq }}
+namespaceSelector:
+  matchExpressions:
+  - key: kubernetes.io/metadata.name
+    operator: NotIn
+    values:
+    {{- toYaml $names | nindent 4 }}
+{{- end }}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/dana-team/env-route-ns-mutator/internal/config"
+	"github.com/dana-team/env-route-ns-mutator/internal/dns"
+	"github.com/dana-team/env-route-ns-mutator/internal/utils"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	_ "k8s.io/client-go/plugin/pkg/client/auth"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var scheme = runtime.NewScheme()
+
+func init() {
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(configv1.Install(scheme))
+}
+
+// dns-records prints the wildcard DNS records that the environments need. The environments
+// and their settings are read from the same configuration file or env vars as the manager.
+func main() {
+	var format string
+	var namespace string
+	var ttl int64
+	var configFile string
+	flag.StringVar(&format, "format", string(dns.FormatBIND), "The output format: bind, json or dnsendpoint.")
+	flag.StringVar(&namespace, "namespace", utils.IngressNamespace, "The namespace of the DNSEndpoint objects.")
+	flag.Int64Var(&ttl, "ttl", dns.DefaultTTL, "The TTL of the records, in seconds.")
+	flag.StringVar(&configFile, "config", "",
+		"The configuration file. The environment variables are read when it is not set.")
+	flag.Parse()
+
+	if err := run(context.Background(), configFile, dns.Format(format), namespace, ttl); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, configFile string, format dns.Format, namespace string, ttl int64) error {
+	k8sClient, err := client.New(ctrl.GetConfigOrDie(), client.Options{Scheme: scheme})
+	if err != nil {
+		return err
+	}
+
+	var cfg *config.Config
+	if len(configFile) > 0 {
+		cfg, err = config.LoadFile(configFile)
+	} else if cfg, err = config.FromEnv(); err == nil {
+		err = config.Validate(cfg)
+	}
+	if err != nil {
+		return err
+	}
+
+	records, err := dns.Records(ctx, k8sClient, cfg.Environments, cfg.EnvironmentSettings, ttl)
+	if err != nil {
+		return err
+	}
+
+	if err := dns.Write(os.Stdout, format, records, namespace); err != nil {
+		return err
+	}
+
+	return dns.Failures(records)
+}
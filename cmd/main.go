@@ -17,20 +17,32 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"crypto/tls"
 	"flag"
 	"os"
+	"strings"
 
+	"github.com/dana-team/env-route-ns-mutator/internal/config"
+	"github.com/dana-team/env-route-ns-mutator/internal/controller"
+	"github.com/dana-team/env-route-ns-mutator/internal/dns"
+	"github.com/dana-team/env-route-ns-mutator/internal/utils"
 	envwebhook "github.com/dana-team/env-route-ns-mutator/internal/webhook"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
 	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
 	routev1 "github.com/openshift/api/route/v1"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/discovery"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	"sigs.k8s.io/controller-runtime/pkg/metrics/filters"
@@ -48,6 +60,7 @@ func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 	utilruntime.Must(routev1.Install(scheme))
 	utilruntime.Must(configv1.Install(scheme))
+	utilruntime.Must(operatorv1.Install(scheme))
 
 	// +kubebuilder:scaffold:scheme
 }
@@ -58,6 +71,10 @@ func main() {
 	var probeAddr string
 	var secureMetrics bool
 	var enableHTTP2 bool
+	var dnsEndpointNamespace string
+	var provisionIngressControllers bool
+	var configMap string
+	var configFile string
 	flag.StringVar(&metricsAddr, "metrics-bind-address", "0", "The address the metrics endpoint binds to. "+
 		"Use :8443 for HTTPS or :8080 for HTTP, or leave as 0 to disable the metrics service.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
@@ -68,6 +85,16 @@ func main() {
 		"If set, the metrics endpoint is served securely via HTTPS. Use --metrics-secure=false to use HTTP instead.")
 	flag.BoolVar(&enableHTTP2, "enable-http2", false,
 		"If set, HTTP/2 will be enabled for the metrics and webhook servers")
+	flag.StringVar(&dnsEndpointNamespace, "dns-endpoint-namespace", utils.IngressNamespace,
+		"The namespace of the DNSEndpoint objects of the environments, when ExternalDNS is installed.")
+	flag.BoolVar(&provisionIngressControllers, "provision-ingress-controllers", false,
+		"If set, an IngressController is provisioned for every environment with router settings.")
+	flag.StringVar(&configFile, "config", "",
+		"The configuration file, which is reloaded when it changes. The environment variables are read when it is not set.")
+	flag.StringVar(&configMap, "config-map", "",
+		"The namespace/name of the ConfigMap which holds the configuration of the environments, "+
+			"on which the status of the provisioned IngressControllers is reported. "+
+			"It must be in the namespace of the manager, where its Roles grant access to ConfigMaps.")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -118,9 +145,30 @@ func main() {
 		// this setup is not recommended for production.
 	}
 
-	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
+	// Only the router certificates and load balancers are read, so Secrets, Services and
+	// IngressControllers are cached in the namespaces of the ingress operator alone.
+	cacheByObject := map[client.Object]cache.ByObject{
+		&corev1.Secret{}:                {Namespaces: map[string]cache.Config{utils.IngressNamespace: {}}},
+		&corev1.Service{}:               {Namespaces: map[string]cache.Config{utils.IngressNamespace: {}}},
+		&operatorv1.IngressController{}: {Namespaces: map[string]cache.Config{utils.IngressOperatorNamespace: {}}},
+	}
+
+	configMapName := types.NamespacedName{}
+	if len(configMap) > 0 {
+		namespace, name, ok := strings.Cut(configMap, "/")
+		if !ok || len(namespace) == 0 || len(name) == 0 {
+			setupLog.Error(nil, "config map must be set as namespace/name", "config-map", configMap)
+			os.Exit(1)
+		}
+		configMapName = types.NamespacedName{Namespace: namespace, Name: name}
+		cacheByObject[&corev1.ConfigMap{}] = cache.ByObject{Namespaces: map[string]cache.Config{namespace: {}}}
+	}
+
+	cfg := ctrl.GetConfigOrDie()
+	mgr, err := ctrl.NewManager(cfg, ctrl.Options{
 		Scheme:                 scheme,
 		Metrics:                metricsServerOptions,
+		Cache:                  cache.Options{ByObject: cacheByObject},
 		WebhookServer:          webhookServer,
 		HealthProbeBindAddress: probeAddr,
 		LeaderElection:         enableLeaderElection,
@@ -144,6 +192,35 @@ func main() {
 
 	// +kubebuilder:scaffold:builder
 
+	// The configuration is validated before the manager starts. A file which is invalid
+	// after a reload is ignored, keeping the active configuration and the replica ready.
+	var configWatcher *config.Watcher
+	if len(configFile) > 0 {
+		configWatcher = &config.Watcher{Path: configFile}
+		if err := configWatcher.Load(); err != nil {
+			setupLog.Error(err, "unable to load configuration", "config", configFile)
+			os.Exit(1)
+		}
+		if err := mgr.Add(configWatcher); err != nil {
+			setupLog.Error(err, "unable to set up configuration watcher")
+			os.Exit(1)
+		}
+	} else {
+		envConfig, err := config.FromEnv()
+		if err == nil {
+			err = config.Validate(envConfig)
+		}
+		if err != nil {
+			setupLog.Error(err, "invalid configuration")
+			os.Exit(1)
+		}
+	}
+
+	if err := envwebhook.SetupIndexes(context.Background(), mgr.GetFieldIndexer()); err != nil {
+		setupLog.Error(err, "unable to set up field indexes")
+		os.Exit(1)
+	}
+
 	setupLog.Info("setting up webhook server")
 	hookServer := mgr.GetWebhookServer()
 	decoder := admission.NewDecoder(scheme)
@@ -153,16 +230,124 @@ func main() {
 		Client:  mgr.GetClient(),
 	}})
 
+	hookServer.Register("/validate-v1-route", &webhook.Admission{Handler: &envwebhook.RouteValidator{
+		Decoder: decoder,
+		Client:  mgr.GetClient(),
+	}})
+
 	hookServer.Register("/mutate-v1-namespace", &webhook.Admission{Handler: &envwebhook.NamespaceMutator{
 		Decoder: decoder,
 		Client:  mgr.GetClient(),
 	}})
 
+	hookServer.Register("/validate-v1-namespace", &webhook.Admission{Handler: &envwebhook.NamespaceValidator{
+		Decoder: decoder,
+		Client:  mgr.GetClient(),
+	}})
+
 	hookServer.Register("/mutate-v1-ingress", &webhook.Admission{Handler: &envwebhook.IngressMutator{
 		Decoder: decoder,
 		Client:  mgr.GetClient(),
 	}})
 
+	hookServer.Register("/validate-v1-ingress", &webhook.Admission{Handler: &envwebhook.IngressValidator{
+		Decoder: decoder,
+		Client:  mgr.GetClient(),
+	}})
+
+	// The records are served by the metrics server, which authenticates and authorizes its
+	// clients, rather than by the webhook server, which the API server calls anonymously.
+	if err := mgr.AddMetricsServerExtraHandler("/dns-records", &dns.Handler{Client: mgr.GetClient()}); err != nil {
+		setupLog.Error(err, "unable to add dns records handler")
+		os.Exit(1)
+	}
+
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(cfg)
+	if err != nil {
+		setupLog.Error(err, "unable to create discovery client")
+		os.Exit(1)
+	}
+
+	// The webhooks of optional resources are only registered when their API group is served.
+	optionalWebhooks := []struct {
+		group   string
+		path    string
+		handler admission.Handler
+	}{
+		{
+			group:   envwebhook.GatewayAPIGroup,
+			path:    "/mutate-v1-gateway-route",
+			handler: &envwebhook.GatewayRouteMutator{Decoder: decoder, Client: mgr.GetClient()},
+		},
+		{
+			group:   envwebhook.IstioNetworkingGroup,
+			path:    "/mutate-v1-istio",
+			handler: &envwebhook.IstioMutator{Decoder: decoder, Client: mgr.GetClient()},
+		},
+		{
+			group:   envwebhook.KnativeServingGroup,
+			path:    "/mutate-v1-knative",
+			handler: &envwebhook.KnativeMutator{Decoder: decoder, Client: mgr.GetClient()},
+		},
+		{
+			group:   envwebhook.CertManagerGroup,
+			path:    "/mutate-v1-certificate",
+			handler: &envwebhook.CertificateMutator{Decoder: decoder, Client: mgr.GetClient()},
+		},
+	}
+
+	for _, optionalWebhook := range optionalWebhooks {
+		available, err := utils.IsGroupAvailable(discoveryClient, optionalWebhook.group)
+		if err != nil {
+			setupLog.Error(err, "unable to discover API group", "group", optionalWebhook.group)
+			os.Exit(1)
+		}
+		if !available {
+			setupLog.Info("API group is not available, skipping webhook",
+				"group", optionalWebhook.group, "path", optionalWebhook.path)
+			continue
+		}
+		hookServer.Register(optionalWebhook.path, &webhook.Admission{Handler: optionalWebhook.handler})
+	}
+
+	// The DNSEndpoints of the environments are only managed when ExternalDNS is installed.
+	available, err := utils.IsGroupAvailable(discoveryClient, dns.ExternalDNSGroup)
+	if err != nil {
+		setupLog.Error(err, "unable to discover API group", "group", dns.ExternalDNSGroup)
+		os.Exit(1)
+	}
+	if available {
+		reconciler := &controller.DNSEndpointReconciler{
+			Client:    mgr.GetClient(),
+			Namespace: dnsEndpointNamespace,
+			TTL:       dns.DefaultTTL,
+		}
+		if configWatcher != nil {
+			reconciler.Reloads = configWatcher.Subscribe()
+		}
+		if err := reconciler.SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "DNSEndpoint")
+			os.Exit(1)
+		}
+	} else {
+		setupLog.Info("API group is not available, skipping controller",
+			"group", dns.ExternalDNSGroup, "controller", "DNSEndpoint")
+	}
+
+	if provisionIngressControllers {
+		reconciler := &controller.IngressControllerReconciler{
+			Client:    mgr.GetClient(),
+			ConfigMap: configMapName,
+		}
+		if configWatcher != nil {
+			reconciler.Reloads = configWatcher.Subscribe()
+		}
+		if err := reconciler.SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "IngressController")
+			os.Exit(1)
+		}
+	}
+
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to set up health check")
 		os.Exit(1)
@@ -171,6 +356,12 @@ func main() {
 		setupLog.Error(err, "unable to set up ready check")
 		os.Exit(1)
 	}
+	if configWatcher != nil {
+		if err := mgr.AddReadyzCheck("config", configWatcher.Check); err != nil {
+			setupLog.Error(err, "unable to set up ready check")
+			os.Exit(1)
+		}
+	}
 
 	setupLog.Info("starting manager")
 	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
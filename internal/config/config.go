@@ -0,0 +1,185 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sync/atomic"
+
+	"github.com/dana-team/env-route-ns-mutator/internal/utils"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/yaml"
+)
+
+// Version is the version of the configuration file.
+const Version = "v1"
+
+// File is the configuration file. Unknown fields are rejected.
+type File struct {
+	// Version is the version of the file, which must be v1.
+	Version string `json:"version"`
+	// Environments are the names of the environments.
+	Environments []string `json:"environments"`
+	// EnvironmentSettings are the settings of the environments, keyed by environment name.
+	EnvironmentSettings map[string]utils.EnvironmentSettings `json:"environmentSettings,omitempty"`
+	// EnvironmentAdminGroups are the groups, or glob patterns of groups, allowed to set the
+	// environment label of a Namespace.
+	EnvironmentAdminGroups []string `json:"environmentAdminGroups,omitempty"`
+	// Exclusions are the namespaces and objects that are never mutated.
+	Exclusions FileExclusions `json:"exclusions,omitempty"`
+	// PlatformRules are the rules exempting objects managed by platform controllers, in
+	// addition to the default rules.
+	PlatformRules []utils.PlatformRule `json:"platformRules,omitempty"`
+	// Bypass is the label of the namespaces, and the users, whose objects are not mutated.
+	Bypass utils.Bypass `json:"bypass,omitempty"`
+}
+
+// FileExclusions are the exclusions of the configuration file. A namespace or object
+// matching any single label requirement is excluded.
+type FileExclusions struct {
+	Namespaces      []string                          `json:"namespaces,omitempty"`
+	NamespaceLabels []metav1.LabelSelectorRequirement `json:"namespaceLabels,omitempty"`
+	ObjectLabels    []metav1.LabelSelectorRequirement `json:"objectLabels,omitempty"`
+}
+
+// Config is a snapshot of the configuration. A snapshot is never modified, so a request
+// which reads it once sees a consistent configuration.
+type Config struct {
+	// Generation is incremented every time a configuration file is loaded. It is zero
+	// when the configuration is read from the environment variables.
+	Generation             int64
+	Environments           []string
+	EnvironmentSettings    map[string]utils.EnvironmentSettings
+	EnvironmentAdminGroups []string
+	Exclusions             utils.Exclusions
+	PlatformRules          []utils.PlatformRule
+	Bypass                 utils.Bypass
+}
+
+// current is the snapshot of the configuration file, if one was loaded.
+var current atomic.Pointer[Config]
+
+// Current returns the active configuration. Without a configuration file, the configuration
+// is read from the environment variables on every call.
+func Current() (*Config, error) {
+	if config := current.Load(); config != nil {
+		return config, nil
+	}
+
+	return FromEnv()
+}
+
+// FromEnv reads the configuration from the environment variables.
+func FromEnv() (*Config, error) {
+	settings, err := utils.GetEnvironmentSettings()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get environment settings: %w", err)
+	}
+
+	exclusions, err := utils.GetExclusions()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get exclusions: %w", err)
+	}
+
+	platformRules, err := utils.GetPlatformRules()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get platform rules: %w", err)
+	}
+
+	return &Config{
+		Environments:           utils.GetEnvironments(),
+		EnvironmentSettings:    settings,
+		EnvironmentAdminGroups: utils.GetEnvironmentAdminGroups(),
+		Exclusions:             exclusions,
+		PlatformRules:          platformRules,
+		Bypass:                 utils.GetBypass(),
+	}, nil
+}
+
+// LoadFile reads and parses a configuration file.
+func LoadFile(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration file %s: %w", path, err)
+	}
+
+	return config, nil
+}
+
+// Parse parses a configuration file strictly, rejecting unknown fields and unknown versions,
+// and validates it.
+func Parse(data []byte) (*Config, error) {
+	file := File{}
+	if err := yaml.UnmarshalStrict(data, &file); err != nil {
+		return nil, err
+	}
+
+	if file.Version != Version {
+		return nil, fmt.Errorf("unsupported version %q, the version must be %q", file.Version, Version)
+	}
+
+	settings, err := utils.ResolveEnvironmentSettings(file.EnvironmentSettings)
+	if err != nil {
+		return nil, err
+	}
+
+	namespaceLabels, err := requirements(file.Exclusions.NamespaceLabels)
+	if err != nil {
+		return nil, fmt.Errorf("invalid namespace label exclusion: %w", err)
+	}
+	objectLabels, err := requirements(file.Exclusions.ObjectLabels)
+	if err != nil {
+		return nil, fmt.Errorf("invalid object label exclusion: %w", err)
+	}
+
+	config := &Config{
+		Environments:           file.Environments,
+		EnvironmentSettings:    settings,
+		EnvironmentAdminGroups: utils.EnvironmentAdminGroups(file.EnvironmentAdminGroups),
+		Exclusions: utils.Exclusions{
+			Namespaces:      file.Exclusions.Namespaces,
+			NamespaceLabels: namespaceLabels,
+			ObjectLabels:    objectLabels,
+		},
+		PlatformRules: append(append([]utils.PlatformRule{}, utils.DefaultPlatformRules...), file.PlatformRules...),
+		Bypass:        file.Bypass,
+	}
+
+	if err := Validate(config); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// requirements converts label selector requirements to label requirements.
+func requirements(selectorRequirements []metav1.LabelSelectorRequirement) (labels.Requirements, error) {
+	var result labels.Requirements
+	for _, selectorRequirement := range selectorRequirements {
+		selector, err := metav1.LabelSelectorAsSelector(&metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{selectorRequirement}})
+		if err != nil {
+			return nil, err
+		}
+		parsed, _ := selector.Requirements()
+		result = append(result, parsed...)
+	}
+
+	return result, nil
+}
+
+// store sets the active configuration, with the generation following the active one.
+func store(config *Config) {
+	if previous := current.Load(); previous != nil {
+		config.Generation = previous.Generation + 1
+	} else {
+		config.Generation = 1
+	}
+
+	current.Store(config)
+	generation.Set(float64(config.Generation))
+}
@@ -0,0 +1,201 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dana-team/env-route-ns-mutator/internal/utils"
+
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	authenticationv1 "k8s.io/api/authentication/v1"
+)
+
+const validConfig = `version: v1
+environments:
+  - env1
+  - env2
+environmentSettings:
+  env1:
+    domain: development
+    hostCollision: Deny
+environmentAdminGroups:
+  - platform-admins
+exclusions:
+  namespaces:
+    - kube-*
+  namespaceLabels:
+    - key: tier
+      operator: In
+      values:
+        - infra
+  objectLabels:
+    - key: skip
+      operator: Exists
+platformRules:
+  - name: argocd
+    manager: argocd-*
+    username: system:serviceaccount:argocd:*
+bypass:
+  label: example.com/bypass
+  users:
+    - system:serviceaccount:ci:*
+`
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   string
+		failed bool
+	}{
+		{name: "validConfig", data: validConfig},
+		{name: "unknownField", data: "version: v1\nenvironments: [env1]\nenvironment: env2\n", failed: true},
+		{name: "unknownSettingsField", data: "version: v1\nenvironments: [env1]\nenvironmentSettings:\n  env1:\n    subdomain: Shard\n", failed: true},
+		{name: "missingVersion", data: "environments: [env1]\n", failed: true},
+		{name: "unsupportedVersion", data: "version: v2\nenvironments: [env1]\n", failed: true},
+		{name: "invalidSettings", data: "version: v1\nenvironments: [env1]\nenvironmentSettings:\n  env1:\n    hostCollision: Ignore\n", failed: true},
+		{name: "sharedDomain", data: "version: v1\nenvironments: [env1, env2]\nenvironmentSettings:\n  env1:\n    domain: env2\n    ingressController: env1\n", failed: true},
+		{name: "untrustedPlatformRule", data: "version: v1\nenvironments: [env1]\nplatformRules:\n  - name: argocd\n    manager: argocd-*\n", failed: true},
+		{name: "invalidBypassLabel", data: "version: v1\nenvironments: [env1]\nbypass:\n  label: not a label\n", failed: true},
+		{name: "invalidEnvironment", data: "version: v1\nenvironments: [env1, Env.2]\n", failed: true},
+		{name: "invalidLabelExclusion", data: "version: v1\nenvironments: [env1]\nexclusions:\n  objectLabels:\n    - key: skip\n      operator: Exists\n      values: [true]\n", failed: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			config, err := Parse([]byte(tc.data))
+			if tc.failed {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(config.Environments).To(Equal([]string{"env1", "env2"}))
+			g.Expect(config.EnvironmentSettings["env1"].HostCollision).To(Equal(utils.HostCollisionDeny))
+			g.Expect(utils.EnvironmentDomain("env1", config.EnvironmentSettings["env1"])).To(Equal("development"))
+			g.Expect(utils.EnvironmentDomain("env2", config.EnvironmentSettings["env2"])).To(Equal("env2"))
+			g.Expect(config.EnvironmentAdminGroups).To(ContainElement("platform-admins"))
+			g.Expect(config.PlatformRules).To(HaveLen(len(utils.DefaultPlatformRules) + 1))
+			g.Expect(config.Bypass.BypassesNamespace(map[string]string{"example.com/bypass": "true"})).To(BeTrue())
+			g.Expect(config.Bypass.BypassesNamespace(map[string]string{utils.DefaultBypassLabel: "true"})).To(BeFalse())
+			_, ok := config.Bypass.MatchUser(authenticationv1.UserInfo{Username: "system:serviceaccount:ci:deployer"})
+			g.Expect(ok).To(BeTrue())
+
+			_, excluded := config.Exclusions.ExcludesNamespaceName("kube-system")
+			g.Expect(excluded).To(BeTrue())
+			_, excluded = config.Exclusions.ExcludesNamespaceLabels(map[string]string{"tier": "infra"})
+			g.Expect(excluded).To(BeTrue())
+			_, excluded = config.Exclusions.ExcludesObjectLabels(map[string]string{"skip": ""})
+			g.Expect(excluded).To(BeTrue())
+		})
+	}
+}
+
+func TestWatcher(t *testing.T) {
+	g := NewWithT(t)
+	current.Store(nil)
+	t.Cleanup(func() { current.Store(nil) })
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	g.Expect(os.WriteFile(path, []byte(validConfig), 0o600)).To(Succeed())
+
+	watcher := &Watcher{Path: path}
+	g.Expect(watcher.Load()).To(Succeed())
+
+	config, err := Current()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(config.Generation).To(Equal(int64(1)))
+	g.Expect(config.Environments).To(Equal([]string{"env1", "env2"}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		_ = watcher.Start(ctx)
+	}()
+
+	// An invalid file is not loaded, the active configuration is kept and the replica stays
+	// ready, while the reload is reported as failed. The file is written until the watcher
+	// picks it up.
+	g.Eventually(func() float64 {
+		g.Expect(os.WriteFile(path, []byte("version: v1\nenvironments: [env3]\nunknown: true\n"), 0o600)).To(Succeed())
+		return testutil.ToFloat64(lastReloadSuccessful)
+	}, 5*time.Second, 100*time.Millisecond).Should(BeZero())
+	g.Expect(watcher.Check(nil)).To(Succeed())
+	config, err = Current()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(config.Environments).To(Equal([]string{"env1", "env2"}))
+
+	g.Expect(os.WriteFile(path, []byte("version: v1\nenvironments: [env3]\n"), 0o600)).To(Succeed())
+	g.Eventually(func() []string {
+		config, _ := Current()
+		return config.Environments
+	}, 5*time.Second).Should(Equal([]string{"env3"}))
+
+	config, err = Current()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(config.Generation).To(BeNumerically(">", 1))
+	g.Expect(testutil.ToFloat64(lastReloadSuccessful)).To(Equal(float64(1)))
+}
+
+func TestWatcherCheck(t *testing.T) {
+	g := NewWithT(t)
+	current.Store(nil)
+	t.Cleanup(func() { current.Store(nil) })
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	watcher := &Watcher{Path: path}
+	g.Expect(watcher.Check(nil)).NotTo(Succeed())
+
+	g.Expect(os.WriteFile(path, []byte("version: v1\nenvironments: [env3]\nunknown: true\n"), 0o600)).To(Succeed())
+	g.Expect(watcher.Load()).NotTo(Succeed())
+	g.Expect(watcher.Check(nil)).NotTo(Succeed())
+
+	g.Expect(os.WriteFile(path, []byte(validConfig), 0o600)).To(Succeed())
+	g.Expect(watcher.Load()).To(Succeed())
+	g.Expect(watcher.Check(nil)).To(Succeed())
+}
+
+func TestWatcherReload(t *testing.T) {
+	g := NewWithT(t)
+	current.Store(nil)
+	t.Cleanup(func() { current.Store(nil) })
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	g.Expect(os.WriteFile(path, []byte(validConfig), 0o600)).To(Succeed())
+
+	watcher := &Watcher{Path: path}
+	g.Expect(watcher.Load()).To(Succeed())
+	reloads := watcher.Subscribe()
+
+	// Loading an unchanged file, as on every event of a ConfigMap update, keeps the
+	// generation and does not notify the subscribers.
+	g.Expect(watcher.Load()).To(Succeed())
+	g.Expect(watcher.Load()).To(Succeed())
+	config, err := Current()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(config.Generation).To(Equal(int64(1)))
+	g.Expect(testutil.ToFloat64(generation)).To(Equal(float64(1)))
+	g.Expect(reloads).NotTo(Receive())
+
+	g.Expect(os.WriteFile(path, []byte("version: v1\nenvironments: [env3]\n"), 0o600)).To(Succeed())
+	g.Expect(watcher.Load()).To(Succeed())
+	config, err = Current()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(config.Generation).To(Equal(int64(2)))
+	g.Expect(reloads).To(Receive())
+}
+
+func TestCurrentFromEnv(t *testing.T) {
+	g := NewWithT(t)
+	current.Store(nil)
+	t.Setenv(utils.Env, "env1,env2")
+
+	config, err := Current()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(config.Generation).To(BeZero())
+	g.Expect(config.Environments).To(Equal([]string{"env1", "env2"}))
+}
@@ -0,0 +1,120 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/dana-team/env-route-ns-mutator/internal/utils"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// reservedEnvironments are names which are not allowed as environments, since they are
+// used by the platform: the default IngressController and the platform namespace prefixes.
+var reservedEnvironments = map[string]bool{
+	utils.DefaultIngressController: true,
+	"openshift":                    true,
+	"kube":                         true,
+}
+
+// Validate checks the configuration and returns an error listing every problem. An
+// environment must be a DNS-1123 label, since it is part of hostnames, label values and
+// object names.
+func Validate(config *Config) error {
+	var errs field.ErrorList
+
+	environmentsPath := field.NewPath("environments")
+	if len(config.Environments) == 0 {
+		errs = append(errs, field.Required(environmentsPath, "at least one environment must be configured"))
+	}
+
+	environments := map[string]bool{}
+	for i, env := range config.Environments {
+		path := environmentsPath.Index(i)
+		for _, msg := range validation.IsDNS1123Label(env) {
+			errs = append(errs, field.Invalid(path, env, msg))
+		}
+		if reservedEnvironments[env] {
+			errs = append(errs, field.Forbidden(path, fmt.Sprintf("%q is a reserved name", env)))
+		}
+		if environments[env] {
+			errs = append(errs, field.Duplicate(path, env))
+		}
+		environments[env] = true
+	}
+
+	settingsPath := field.NewPath("environmentSettings")
+	envs := make([]string, 0, len(config.EnvironmentSettings))
+	for env := range config.EnvironmentSettings {
+		envs = append(envs, env)
+	}
+	sort.Strings(envs)
+	provisioned := map[string]string{}
+	for _, env := range envs {
+		path := settingsPath.Key(env)
+		if !environments[env] {
+			errs = append(errs, field.Invalid(path, env, "not a configured environment"))
+		}
+		if parent := config.EnvironmentSettings[env].Parent; len(parent) > 0 && !environments[parent] {
+			errs = append(errs, field.Invalid(path.Child("parent"), parent, "not a configured environment"))
+		}
+		errs = append(errs, utils.ValidateEnvironment(env, config.EnvironmentSettings[env], path)...)
+		if envSettings := config.EnvironmentSettings[env]; envSettings.Router != nil {
+			name := utils.IngressControllerName(envSettings)
+			if other, ok := provisioned[name]; ok {
+				errs = append(errs, field.Invalid(path.Child("ingressController"), name, fmt.Sprintf("already provisioned for environment %q", other)))
+			}
+			provisioned[name] = env
+		}
+	}
+
+	// Environments sharing a domain must not publish different DNS records for it, and the
+	// domains composed by hostname dimensions must not overlap with the domains of other
+	// environments.
+	for i, env := range config.Environments {
+		for _, other := range config.Environments[:i] {
+			envSettings, otherSettings := config.EnvironmentSettings[env], config.EnvironmentSettings[other]
+			domain := utils.EnvironmentDomain(env, envSettings)
+			switch {
+			case env == other:
+			case domain == utils.EnvironmentDomain(other, otherSettings):
+				if domainsConflict(envSettings, otherSettings) {
+					errs = append(errs, field.Invalid(settingsPath.Key(env).Child("domain"), domain, fmt.Sprintf("already the domain of environment %q, which is served by another router", other)))
+				}
+			case envSettings.HostnameDimensions == nil && otherSettings.HostnameDimensions == nil:
+			case utils.DomainsOverlap(env, envSettings, other, otherSettings):
+				errs = append(errs, field.Invalid(settingsPath.Key(env).Child("hostnameDimensions"), domain, fmt.Sprintf("composes domains of environment %q", other)))
+			}
+		}
+	}
+
+	platformRulesPath := field.NewPath("platformRules")
+	for i, rule := range config.PlatformRules {
+		if rule.MatchesClientFieldsOnly() && !rule.TrustClientFields {
+			errs = append(errs, field.Invalid(platformRulesPath.Index(i), rule.Name, "matches only on ownerReferences or managedFields, which the client sets: set a username or group, or trustClientFields"))
+		}
+	}
+
+	if label := config.Bypass.Label; len(label) > 0 {
+		for _, msg := range validation.IsQualifiedName(label) {
+			errs = append(errs, field.Invalid(field.NewPath("bypass", "label"), label, msg))
+		}
+	}
+
+	return errs.ToAggregate()
+}
+
+// domainsConflict checks if two environments sharing a domain would both provision an
+// IngressController for it, or would both publish its wildcard DNS record with the
+// addresses of different IngressControllers. Environments with hostname dimensions publish
+// no DNS record.
+func domainsConflict(settings, otherSettings utils.EnvironmentSettings) bool {
+	if settings.Router != nil && otherSettings.Router != nil {
+		return true
+	}
+	if settings.HostnameDimensions != nil || otherSettings.HostnameDimensions != nil {
+		return false
+	}
+
+	return utils.IngressControllerName(settings) != utils.IngressControllerName(otherSettings)
+}
@@ -0,0 +1,200 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dana-team/env-route-ns-mutator/internal/utils"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name           string
+		config         Config
+		expectedErrors []string
+	}{
+		{name: "validEnvironments", config: Config{Environments: []string{"env1", "env-2"}, EnvironmentSettings: map[string]utils.EnvironmentSettings{"env1": {}}}},
+		{name: "noEnvironments", config: Config{}, expectedErrors: []string{"environments: Required value"}},
+		{name: "upperCaseEnvironment", config: Config{Environments: []string{"Env1"}}, expectedErrors: []string{`environments[0]: Invalid value: "Env1"`}},
+		{name: "environmentWithSpace", config: Config{Environments: []string{"env 1"}}, expectedErrors: []string{`environments[0]: Invalid value: "env 1"`}},
+		{name: "environmentWithDot", config: Config{Environments: []string{"env.1"}}, expectedErrors: []string{`environments[0]: Invalid value: "env.1"`}},
+		{name: "longEnvironment", config: Config{Environments: []string{strings.Repeat("e", 64)}}, expectedErrors: []string{"must be no more than 63 characters"}},
+		{name: "reservedEnvironment", config: Config{Environments: []string{"env1", utils.DefaultIngressController}}, expectedErrors: []string{`environments[1]: Forbidden: "default" is a reserved name`}},
+		{name: "duplicateEnvironment", config: Config{Environments: []string{"env1", "env2", "env1"}}, expectedErrors: []string{`environments[2]: Duplicate value: "env1"`}},
+		{name: "settingsOfUnknownEnvironment", config: Config{Environments: []string{"env1"}, EnvironmentSettings: map[string]utils.EnvironmentSettings{"env2": {}}}, expectedErrors: []string{`environmentSettings[env2]: Invalid value: "env2": not a configured environment`}},
+		{name: "invalidSettings", config: Config{Environments: []string{"env1"}, EnvironmentSettings: map[string]utils.EnvironmentSettings{"env1": {HostCollision: "Ignore"}}}, expectedErrors: []string{`environmentSettings[env1].hostCollision: Unsupported value: "Ignore"`}},
+		{
+			name: "sharedProvisionedIngressController",
+			config: Config{
+				Environments: []string{"env1", "env2"},
+				EnvironmentSettings: map[string]utils.EnvironmentSettings{
+					"env1": {IngressController: "shared", Router: &utils.Router{}},
+					"env2": {IngressController: "shared", Router: &utils.Router{}},
+				},
+			},
+			expectedErrors: []string{`environmentSettings[env2].ingressController: Invalid value: "shared": already provisioned for environment "env1"`},
+		},
+		{
+			name: "sharedIngressControllerWithoutRouter",
+			config: Config{
+				Environments: []string{"env1", "env2"},
+				EnvironmentSettings: map[string]utils.EnvironmentSettings{
+					"env1": {IngressController: "shared", Router: &utils.Router{}},
+					"env2": {IngressController: "shared"},
+				},
+			},
+		},
+		{
+			name: "dimensionsComposingDomainOfAnotherEnvironment",
+			config: Config{
+				Environments: []string{"prod", "prod-eu"},
+				EnvironmentSettings: map[string]utils.EnvironmentSettings{
+					"prod": {HostnameDimensions: &utils.HostnameDimensions{Labels: []string{utils.Key, "region"}}},
+				},
+			},
+			expectedErrors: []string{`environmentSettings[prod-eu].hostnameDimensions: Invalid value: "prod-eu": composes domains of environment "prod"`},
+		},
+		{
+			name: "dimensionsWithDistinctSeparator",
+			config: Config{
+				Environments: []string{"prod", "prod-eu"},
+				EnvironmentSettings: map[string]utils.EnvironmentSettings{
+					"prod": {HostnameDimensions: &utils.HostnameDimensions{Labels: []string{utils.Key, "region"}, Separator: "."}},
+				},
+			},
+		},
+		{
+			name: "dimensionsComposingDomainsOfEachOther",
+			config: Config{
+				Environments: []string{"prod", "prod-eu"},
+				EnvironmentSettings: map[string]utils.EnvironmentSettings{
+					"prod":    {HostnameDimensions: &utils.HostnameDimensions{Labels: []string{utils.Key, "region"}}},
+					"prod-eu": {HostnameDimensions: &utils.HostnameDimensions{Labels: []string{utils.Key, "zone"}}},
+				},
+			},
+			expectedErrors: []string{`environmentSettings[prod-eu].hostnameDimensions: Invalid value: "prod-eu": composes domains of environment "prod"`},
+		},
+		{
+			name: "dimensionsOnDifferentEnds",
+			config: Config{
+				Environments: []string{"env1", "env2"},
+				EnvironmentSettings: map[string]utils.EnvironmentSettings{
+					"env1": {HostnameDimensions: &utils.HostnameDimensions{Labels: []string{utils.Key, "region"}}},
+					"env2": {HostnameDimensions: &utils.HostnameDimensions{Labels: []string{"region", utils.Key}}},
+				},
+			},
+			expectedErrors: []string{`environmentSettings[env2].hostnameDimensions: Invalid value: "env2": composes domains of environment "env1"`},
+		},
+		{
+			name: "dimensionsOfDistinctEnvironments",
+			config: Config{
+				Environments: []string{"env1", "env2", "env3"},
+				EnvironmentSettings: map[string]utils.EnvironmentSettings{
+					"env1": {HostnameDimensions: &utils.HostnameDimensions{Labels: []string{utils.Key, "region"}}},
+					"env2": {HostnameDimensions: &utils.HostnameDimensions{Labels: []string{utils.Key, "zone"}}},
+				},
+			},
+		},
+		{
+			name: "sharedDomain",
+			config: Config{
+				Environments:        []string{"prod", "prod-eu"},
+				EnvironmentSettings: map[string]utils.EnvironmentSettings{"prod": {Domain: "production"}, "prod-eu": {Parent: "prod", Domain: "production"}},
+			},
+		},
+		{
+			name: "sharedDomainWithDimensions",
+			config: Config{
+				Environments: []string{"prod", "prod-eu"},
+				EnvironmentSettings: map[string]utils.EnvironmentSettings{
+					"prod":    {Domain: "production", IngressController: "prod"},
+					"prod-eu": {Domain: "production", HostnameDimensions: &utils.HostnameDimensions{Labels: []string{utils.Key, "region"}}},
+				},
+			},
+		},
+		{
+			name: "sharedDomainServedByDifferentRouters",
+			config: Config{
+				Environments:        []string{"prod", "prod-eu"},
+				EnvironmentSettings: map[string]utils.EnvironmentSettings{"prod": {Domain: "production"}, "prod-eu": {Domain: "production", IngressController: "prod-eu"}},
+			},
+			expectedErrors: []string{`environmentSettings[prod-eu].domain: Invalid value: "production": already the domain of environment "prod"`},
+		},
+		{
+			name: "sharedDomainOfProvisionedRouters",
+			config: Config{
+				Environments: []string{"prod", "prod-eu"},
+				EnvironmentSettings: map[string]utils.EnvironmentSettings{
+					"prod":    {Domain: "production", IngressController: "prod", Router: &utils.Router{}},
+					"prod-eu": {Domain: "production", IngressController: "prod-eu", Router: &utils.Router{}},
+				},
+			},
+			expectedErrors: []string{`environmentSettings[prod-eu].domain: Invalid value: "production": already the domain of environment "prod"`},
+		},
+		{
+			name: "domainOfAnotherEnvironment",
+			config: Config{
+				Environments:        []string{"env1", "env2"},
+				EnvironmentSettings: map[string]utils.EnvironmentSettings{"env2": {Domain: "env1", IngressController: "env2"}},
+			},
+			expectedErrors: []string{`environmentSettings[env2].domain: Invalid value: "env1": already the domain of environment "env1"`},
+		},
+		{
+			name:           "untrustedPlatformRule",
+			config:         Config{Environments: []string{"env1"}, PlatformRules: []utils.PlatformRule{{Name: "argocd", OwnerKind: "Application"}}},
+			expectedErrors: []string{`platformRules[0]: Invalid value: "argocd": matches only on ownerReferences or managedFields`},
+		},
+		{name: "trustedPlatformRule", config: Config{Environments: []string{"env1"}, PlatformRules: []utils.PlatformRule{{Name: "argocd", OwnerKind: "Application", TrustClientFields: true}}}},
+		{name: "platformRuleOfServiceAccount", config: Config{Environments: []string{"env1"}, PlatformRules: []utils.PlatformRule{{Name: "argocd", Manager: "argocd-*", Username: "system:serviceaccount:argocd:*"}}}},
+		{
+			name: "everySettingsProblemListed",
+			config: Config{
+				Environments: []string{"env1"},
+				EnvironmentSettings: map[string]utils.EnvironmentSettings{"env1": {
+					HostCollision:            "Ignore",
+					RouteTLS:                 &utils.RouteTLS{Termination: "none"},
+					HostnameDimensions:       &utils.HostnameDimensions{Labels: []string{"region", "region"}},
+					EnforcedRouteAnnotations: []string{"haproxy.router.openshift.io/timeout"},
+				}},
+			},
+			expectedErrors: []string{
+				`environmentSettings[env1].hostCollision: Unsupported value: "Ignore"`,
+				`environmentSettings[env1].routeTLS.termination: Unsupported value: "none"`,
+				`environmentSettings[env1].hostnameDimensions.labels: Invalid value`,
+				`environmentSettings[env1].hostnameDimensions.labels[1]: Duplicate value: "region"`,
+				`environmentSettings[env1].enforcedRouteAnnotations[0]: Invalid value: "haproxy.router.openshift.io/timeout"`,
+			},
+		},
+		{
+			name: "everyProblemListed",
+			config: Config{
+				Environments:        []string{"Env1", "env2", "env2"},
+				EnvironmentSettings: map[string]utils.EnvironmentSettings{"env3": {SubdomainPolicy: "Drop"}},
+			},
+			expectedErrors: []string{
+				`environments[0]: Invalid value: "Env1"`,
+				`environments[2]: Duplicate value: "env2"`,
+				`environmentSettings[env3]: Invalid value: "env3": not a configured environment`,
+				`environmentSettings[env3].subdomainPolicy: Unsupported value: "Drop"`,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			err := Validate(&tc.config)
+			if len(tc.expectedErrors) == 0 {
+				g.Expect(err).NotTo(HaveOccurred())
+				return
+			}
+			g.Expect(err).To(HaveOccurred())
+			for _, expectedError := range tc.expectedErrors {
+				g.Expect(err.Error()).To(ContainSubstring(expectedError))
+			}
+		})
+	}
+}
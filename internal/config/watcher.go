@@ -0,0 +1,164 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/prometheus/client_golang/prometheus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// generation is the generation of the active configuration file.
+var generation = prometheus.NewGauge(prometheus.GaugeOpts{
+	Name: "env_route_ns_mutator_config_generation",
+	Help: "Generation of the active configuration file, incremented on every successful reload.",
+})
+
+// lastReloadSuccessful is 1 when the configuration file was last loaded successfully, and
+// 0 while the file is invalid and an older generation stays active.
+var lastReloadSuccessful = prometheus.NewGauge(prometheus.GaugeOpts{
+	Name: "env_route_ns_mutator_config_last_reload_successful",
+	Help: "Whether the last reload of the configuration file was successful.",
+})
+
+func init() {
+	metrics.Registry.MustRegister(generation, lastReloadSuccessful)
+}
+
+// Watcher loads a configuration file and reloads it when it changes. An invalid file is
+// not loaded, so the previous configuration stays active.
+type Watcher struct {
+	Path string
+
+	mu          sync.Mutex
+	lastErr     error
+	data        []byte
+	subscribers []chan event.GenericEvent
+}
+
+// Subscribe returns a channel which receives an event every time a changed configuration
+// is loaded, so that controllers reconcile it right away. Events which are not consumed
+// yet are coalesced.
+func (w *Watcher) Subscribe() <-chan event.GenericEvent {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	subscriber := make(chan event.GenericEvent, 1)
+	w.subscribers = append(w.subscribers, subscriber)
+	return subscriber
+}
+
+// Load loads the configuration file and makes it the active configuration. A file whose
+// content did not change is not loaded again, since a single update of a mounted ConfigMap
+// raises several events.
+func (w *Watcher) Load() error {
+	data, err := os.ReadFile(w.Path)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var config *Config
+	if err == nil {
+		if current.Load() != nil && bytes.Equal(data, w.data) {
+			w.lastErr = nil
+			lastReloadSuccessful.Set(1)
+			return nil
+		}
+		if config, err = Parse(data); err != nil {
+			err = fmt.Errorf("invalid configuration file %s: %w", w.Path, err)
+		}
+	}
+
+	w.lastErr = err
+	if err != nil {
+		lastReloadSuccessful.Set(0)
+		return err
+	}
+	lastReloadSuccessful.Set(1)
+
+	w.data = data
+	store(config)
+	for _, subscriber := range w.subscribers {
+		select {
+		case subscriber <- event.GenericEvent{Object: &metav1.PartialObjectMetadata{ObjectMeta: metav1.ObjectMeta{Name: filepath.Base(w.Path)}}}:
+		default:
+		}
+	}
+
+	return nil
+}
+
+// Check is a readiness check which fails only while no configuration is active. An invalid
+// reload keeps the active configuration, so the replicas stay ready and keep serving the
+// webhooks, and is reported by the env_route_ns_mutator_config_last_reload_successful metric.
+func (w *Watcher) Check(_ *http.Request) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if current.Load() != nil {
+		return nil
+	}
+	if w.lastErr != nil {
+		return w.lastErr
+	}
+
+	return fmt.Errorf("configuration file %s is not loaded", w.Path)
+}
+
+// Start watches the directory of the configuration file until the context is done. The
+// directory is watched rather than the file, since a mounted ConfigMap is updated by
+// swapping a symlink.
+func (w *Watcher) Start(ctx context.Context) error {
+	logger := log.FromContext(ctx).WithName("config").WithValues("path", w.Path)
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	if err := watcher.Add(filepath.Dir(w.Path)); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if !event.Has(fsnotify.Create) && !event.Has(fsnotify.Write) && !event.Has(fsnotify.Rename) {
+				continue
+			}
+			active := current.Load()
+			if err := w.Load(); err != nil {
+				logger.Error(err, "failed to reload configuration, keeping the active configuration")
+				continue
+			}
+			if reloaded := current.Load(); reloaded != active {
+				logger.Info("Reloaded configuration", "generation", reloaded.Generation)
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			logger.Error(err, "failed to watch configuration")
+		}
+	}
+}
+
+// NeedLeaderElection returns false, since every replica serves the webhooks.
+func (w *Watcher) NeedLeaderElection() bool {
+	return false
+}
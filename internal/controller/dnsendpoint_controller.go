@@ -0,0 +1,177 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dana-team/env-route-ns-mutator/internal/config"
+	"github.com/dana-team/env-route-ns-mutator/internal/dns"
+	"github.com/dana-team/env-route-ns-mutator/internal/utils"
+	configv1 "github.com/openshift/api/config/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	// dnsEndpointsRequest is the single request of the reconciler, which reconciles the
+	// DNSEndpoints of all the environments at once.
+	dnsEndpointsRequest = "dns-endpoints"
+	// dnsEndpointsResync is the interval at which the DNSEndpoints are reconciled, so that
+	// added and removed environments are picked up.
+	dnsEndpointsResync = 5 * time.Minute
+)
+
+// DNSEndpointGVK is the GroupVersionKind of the ExternalDNS DNSEndpoint.
+var DNSEndpointGVK = schema.GroupVersionKind{Group: dns.ExternalDNSGroup, Version: "v1alpha1", Kind: dns.DNSEndpointKind}
+
+// +kubebuilder:rbac:groups="externaldns.k8s.io",resources=dnsendpoints,verbs=get;list;watch;create;update;delete
+
+// DNSEndpointReconciler owns an ExternalDNS DNSEndpoint per environment, pointing the
+// wildcard record of the environment at the load balancer of its router. DNSEndpoints are
+// handled as unstructured objects so that ExternalDNS is not a hard dependency.
+type DNSEndpointReconciler struct {
+	Client    client.Client
+	Namespace string
+	TTL       int64
+	// Reloads receives an event when the configuration file is reloaded. The DNSEndpoints
+	// are only reconciled on their resync when it is nil.
+	Reloads <-chan event.GenericEvent
+}
+
+// Reconcile creates or updates the DNSEndpoint of every environment, and deletes the
+// DNSEndpoints of environments which were removed.
+func (r *DNSEndpointReconciler) Reconcile(ctx context.Context, _ ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx).WithName("DNSEndpoint")
+
+	cfg, err := config.Current()
+	if err != nil {
+		logger.Error(err, "failed to get configuration")
+		return ctrl.Result{}, err
+	}
+	settings := cfg.EnvironmentSettings
+
+	clusterIngress, err := utils.GetClusterIngressDomain(ctx, r.Client)
+	if err != nil {
+		logger.Error(err, "failed to get cluster ingress domain")
+		return ctrl.Result{}, err
+	}
+
+	environments := map[string]bool{}
+	var errs []error
+	for _, env := range cfg.Environments {
+		if len(env) == 0 || settings[env].HostnameDimensions != nil {
+			continue
+		}
+		environments[env] = true
+
+		record, err := dns.EnvironmentRecord(ctx, r.Client, env, clusterIngress, settings[env], r.TTL)
+		if err != nil {
+			logger.Error(err, "failed to get dns record", "environment", env)
+			errs = append(errs, err)
+			continue
+		}
+
+		if err := r.apply(ctx, dns.DNSEndpoint(record, r.Namespace)); err != nil {
+			logger.Error(err, "failed to apply DNSEndpoint", "environment", env)
+			errs = append(errs, err)
+		}
+	}
+
+	if err := r.collect(ctx, environments); err != nil {
+		logger.Error(err, "failed to delete DNSEndpoints of removed environments")
+		errs = append(errs, err)
+	}
+
+	if len(errs) > 0 {
+		return ctrl.Result{}, errors.Join(errs...)
+	}
+
+	return ctrl.Result{RequeueAfter: dnsEndpointsResync}, nil
+}
+
+// apply creates the DNSEndpoint, or updates its spec when it changed. A DNSEndpoint which
+// already exists without the managed-by label is never taken over.
+func (r *DNSEndpointReconciler) apply(ctx context.Context, endpoint *unstructured.Unstructured) error {
+	endpoint.SetLabels(utils.AppendLabels(endpoint.GetLabels(), map[string]string{ManagedByLabel: ManagedBy}))
+
+	existing := &unstructured.Unstructured{}
+	existing.SetGroupVersionKind(DNSEndpointGVK)
+	if err := r.Client.Get(ctx, client.ObjectKeyFromObject(endpoint), existing); err != nil {
+		if apierrors.IsNotFound(err) {
+			log.FromContext(ctx).Info("Creating DNSEndpoint", "name", endpoint.GetName(), "namespace", endpoint.GetNamespace())
+			return r.Client.Create(ctx, endpoint)
+		}
+		return err
+	}
+
+	if existing.GetLabels()[ManagedByLabel] != ManagedBy {
+		return fmt.Errorf("DNSEndpoint %q already exists and is not managed by %s", existing.GetName(), ManagedBy)
+	}
+
+	if equality.Semantic.DeepEqual(existing.Object["spec"], endpoint.Object["spec"]) {
+		return nil
+	}
+
+	existing.Object["spec"] = endpoint.Object["spec"]
+	existing.SetLabels(utils.AppendLabels(existing.GetLabels(), endpoint.GetLabels()))
+	log.FromContext(ctx).Info("Updating DNSEndpoint", "name", endpoint.GetName(), "namespace", endpoint.GetNamespace())
+	return r.Client.Update(ctx, existing)
+}
+
+// collect deletes the managed DNSEndpoints of environments which are no longer configured.
+func (r *DNSEndpointReconciler) collect(ctx context.Context, environments map[string]bool) error {
+	endpoints := &unstructured.UnstructuredList{}
+	endpoints.SetGroupVersionKind(DNSEndpointGVK.GroupVersion().WithKind(DNSEndpointGVK.Kind + "List"))
+	if err := r.Client.List(ctx, endpoints, client.InNamespace(r.Namespace), client.MatchingLabels{ManagedByLabel: ManagedBy}); err != nil {
+		return err
+	}
+
+	for i := range endpoints.Items {
+		endpoint := &endpoints.Items[i]
+		if environments[endpoint.GetLabels()[utils.Key]] {
+			continue
+		}
+
+		log.FromContext(ctx).Info("Deleting DNSEndpoint of removed environment", "name", endpoint.GetName(), "namespace", endpoint.GetNamespace())
+		if err := r.Client.Delete(ctx, endpoint); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SetupWithManager sets up the reconciler with the Manager. It is reconciled when a
+// router Service, the cluster Ingress, a managed DNSEndpoint or the configuration changes.
+func (r *DNSEndpointReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	enqueue := enqueueRequest(dnsEndpointsRequest)
+
+	endpoint := &unstructured.Unstructured{}
+	endpoint.SetGroupVersionKind(DNSEndpointGVK)
+
+	controllerBuilder := ctrl.NewControllerManagedBy(mgr).
+		Named("dnsendpoint").
+		Watches(endpoint, enqueue, builder.WithPredicates(managedPredicate)).
+		Watches(&corev1.Service{}, enqueue, builder.WithPredicates(predicate.NewPredicateFuncs(func(obj client.Object) bool {
+			return obj.GetNamespace() == utils.IngressNamespace && strings.HasPrefix(obj.GetName(), dns.RouterServicePrefix)
+		}))).
+		Watches(&configv1.Ingress{}, enqueue)
+	if r.Reloads != nil {
+		controllerBuilder = controllerBuilder.WatchesRawSource(source.Channel(r.Reloads, enqueue))
+	}
+
+	return controllerBuilder.Complete(r)
+}
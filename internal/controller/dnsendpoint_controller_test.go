@@ -0,0 +1,119 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/dana-team/env-route-ns-mutator/internal/dns"
+	"github.com/dana-team/env-route-ns-mutator/internal/utils"
+
+	. "github.com/onsi/gomega"
+	configv1 "github.com/openshift/api/config/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	testclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const (
+	clusterIngressDomain = "apps.ocp-test.os-test.com"
+	routerIP             = "10.0.0.1"
+)
+
+func dnsEndpoint(env string, labels map[string]string, targets ...interface{}) *unstructured.Unstructured {
+	endpoint := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"endpoints": []interface{}{
+				map[string]interface{}{
+					"dnsName":    fmt.Sprintf("*.%s-%s", env, clusterIngressDomain),
+					"recordType": dns.RecordTypeA,
+					"recordTTL":  int64(dns.DefaultTTL),
+					"targets":    targets,
+				},
+			},
+		},
+	}}
+	endpoint.SetGroupVersionKind(DNSEndpointGVK)
+	endpoint.SetName(env)
+	endpoint.SetNamespace(utils.IngressNamespace)
+	endpoint.SetLabels(labels)
+
+	return endpoint
+}
+
+func routerService(ingressController string, ips ...string) *corev1.Service {
+	service := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: dns.RouterServicePrefix + ingressController, Namespace: utils.IngressNamespace}}
+	for _, ip := range ips {
+		service.Status.LoadBalancer.Ingress = append(service.Status.LoadBalancer.Ingress, corev1.LoadBalancerIngress{IP: ip})
+	}
+
+	return service
+}
+
+func TestDNSEndpointReconciler(t *testing.T) {
+	managedLabels := func(env string) map[string]string {
+		return map[string]string{utils.Key: env, ManagedByLabel: ManagedBy}
+	}
+
+	tests := []struct {
+		name              string
+		environments      string
+		objects           []client.Object
+		expectedEndpoints map[string][]interface{}
+		failed            bool
+	}{
+		{name: "createEndpoints", environments: "env1,env2", objects: []client.Object{routerService("env2", routerIP)}, expectedEndpoints: map[string][]interface{}{"env1": {routerIP}, "env2": {routerIP}}},
+		{name: "updateEndpoint", environments: "env1", objects: []client.Object{dnsEndpoint("env1", managedLabels("env1"), "10.0.0.2")}, expectedEndpoints: map[string][]interface{}{"env1": {routerIP}}},
+		{name: "deleteEndpointOfRemovedEnvironment", environments: "env1", objects: []client.Object{dnsEndpoint("env2", managedLabels("env2"), routerIP)}, expectedEndpoints: map[string][]interface{}{"env1": {routerIP}}},
+		{name: "keepUnmanagedEndpoint", environments: "env1", objects: []client.Object{dnsEndpoint("env3", map[string]string{utils.Key: "env3"}, routerIP)}, expectedEndpoints: map[string][]interface{}{"env1": {routerIP}, "env3": {routerIP}}},
+		{name: "refuseUnmanagedEndpoint", environments: "env1", objects: []client.Object{dnsEndpoint("env1", map[string]string{utils.Key: "env1"}, "10.0.0.2")}, expectedEndpoints: map[string][]interface{}{"env1": {"10.0.0.2"}}, failed: true},
+		{name: "noEnvironments", environments: "", objects: []client.Object{dnsEndpoint("env1", managedLabels("env1"), routerIP)}, expectedEndpoints: map[string][]interface{}{}},
+		{name: "routerWithoutAddress", environments: "env1,env2", objects: []client.Object{routerService("env2")}, expectedEndpoints: map[string][]interface{}{"env1": {routerIP}}, failed: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			t.Setenv(utils.Env, tc.environments)
+			t.Setenv(utils.EnvironmentSettingsEnv, "env2:\n  ingressController: env2\n")
+
+			testScheme := runtime.NewScheme()
+			utilruntime.Must(scheme.AddToScheme(testScheme))
+			utilruntime.Must(configv1.Install(testScheme))
+			testScheme.AddKnownTypeWithName(DNSEndpointGVK, &unstructured.Unstructured{})
+			testScheme.AddKnownTypeWithName(DNSEndpointGVK.GroupVersion().WithKind(DNSEndpointGVK.Kind+"List"), &unstructured.UnstructuredList{})
+
+			objects := append([]client.Object{
+				&configv1.Ingress{ObjectMeta: metav1.ObjectMeta{Name: "cluster"}, Spec: configv1.IngressSpec{Domain: clusterIngressDomain}},
+				routerService(utils.DefaultIngressController, routerIP),
+			}, tc.objects...)
+			k8sClient := testclient.NewClientBuilder().WithScheme(testScheme).WithObjects(objects...).Build()
+
+			reconciler := &DNSEndpointReconciler{Client: k8sClient, Namespace: utils.IngressNamespace, TTL: dns.DefaultTTL}
+			_, err := reconciler.Reconcile(context.Background(), ctrl.Request{})
+			if tc.failed {
+				g.Expect(err).To(HaveOccurred())
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+
+			endpoints := &unstructured.UnstructuredList{}
+			endpoints.SetGroupVersionKind(DNSEndpointGVK.GroupVersion().WithKind(DNSEndpointGVK.Kind + "List"))
+			g.Expect(k8sClient.List(context.Background(), endpoints)).To(Succeed())
+
+			actual := map[string][]interface{}{}
+			for _, endpoint := range endpoints.Items {
+				records, _, _ := unstructured.NestedSlice(endpoint.Object, "spec", "endpoints")
+				g.Expect(records).To(HaveLen(1))
+				actual[endpoint.GetName()] = records[0].(map[string]interface{})["targets"].([]interface{})
+			}
+			g.Expect(actual).To(Equal(tc.expectedEndpoints))
+		})
+	}
+}
@@ -0,0 +1,264 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dana-team/env-route-ns-mutator/internal/config"
+	"github.com/dana-team/env-route-ns-mutator/internal/utils"
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	// StatusAnnotationPrefix prefixes the annotations of the configuration ConfigMap which
+	// hold the status of the IngressController of each environment.
+	StatusAnnotationPrefix = "status.environment.dana.io/"
+
+	// ingressControllersRequest is the single request of the reconciler, which reconciles
+	// the IngressControllers of all the environments at once.
+	ingressControllersRequest = "ingress-controllers"
+	// ingressControllersResync is the interval at which the IngressControllers are
+	// reconciled, so that added and removed environments are picked up.
+	ingressControllersResync = 5 * time.Minute
+)
+
+// +kubebuilder:rbac:groups="operator.openshift.io",resources=ingresscontrollers,verbs=get;list;watch;create;update;delete
+// The status is reported on a ConfigMap in the namespace of the manager, through the
+// ConfigMap permissions of its namespaced Roles.
+
+// EnvironmentStatus is the status of the IngressController of an environment.
+type EnvironmentStatus struct {
+	IngressController string `json:"ingressController"`
+	Domain            string `json:"domain,omitempty"`
+	Available         bool   `json:"available"`
+	Message           string `json:"message,omitempty"`
+}
+
+// IngressControllerReconciler provisions an IngressController for every environment with
+// router settings, serving the domain of the environment to its Namespaces, and reports
+// their status on the configuration ConfigMap.
+type IngressControllerReconciler struct {
+	Client client.Client
+	// ConfigMap is the ConfigMap which holds the configuration of the environments. The
+	// status is not reported when it is empty.
+	ConfigMap types.NamespacedName
+	// Reloads receives an event when the configuration file is reloaded, which may happen
+	// well after the ConfigMap changed.
+	Reloads <-chan event.GenericEvent
+}
+
+// Reconcile creates or updates the IngressController of every environment with router
+// settings, and deletes the IngressControllers of environments which were removed.
+func (r *IngressControllerReconciler) Reconcile(ctx context.Context, _ ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx).WithName("IngressController")
+
+	cfg, err := config.Current()
+	if err != nil {
+		logger.Error(err, "failed to get configuration")
+		return ctrl.Result{}, err
+	}
+	settings := cfg.EnvironmentSettings
+
+	clusterIngress, err := utils.GetClusterIngressDomain(ctx, r.Client)
+	if err != nil {
+		logger.Error(err, "failed to get cluster ingress domain")
+		return ctrl.Result{}, err
+	}
+
+	provisioned := map[string]bool{}
+	statuses := map[string]EnvironmentStatus{}
+	var errs []error
+	for _, env := range cfg.Environments {
+		envSettings := settings[env]
+		if len(env) == 0 || envSettings.Router == nil {
+			continue
+		}
+
+		ingressController := desiredIngressController(env, clusterIngress, envSettings)
+		provisioned[ingressController.Name] = true
+
+		status, err := r.apply(ctx, ingressController)
+		if err != nil {
+			logger.Error(err, "failed to apply IngressController", "environment", env)
+			errs = append(errs, err)
+			status = EnvironmentStatus{IngressController: ingressController.Name, Message: err.Error()}
+		}
+		statuses[env] = status
+	}
+
+	if err := r.collect(ctx, provisioned); err != nil {
+		logger.Error(err, "failed to delete IngressControllers of removed environments")
+		errs = append(errs, err)
+	}
+
+	if err := r.reportStatus(ctx, statuses); err != nil {
+		logger.Error(err, "failed to report environment status")
+		errs = append(errs, err)
+	}
+
+	if len(errs) > 0 {
+		return ctrl.Result{}, errors.Join(errs...)
+	}
+
+	return ctrl.Result{RequeueAfter: ingressControllersResync}, nil
+}
+
+// desiredIngressController returns the IngressController of an environment.
+func desiredIngressController(env, clusterIngress string, settings utils.EnvironmentSettings) *operatorv1.IngressController {
+	return &operatorv1.IngressController{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      utils.IngressControllerName(settings),
+			Namespace: utils.IngressOperatorNamespace,
+			Labels:    map[string]string{utils.Key: env, ManagedByLabel: ManagedBy},
+		},
+		Spec: operatorv1.IngressControllerSpec{
+			Domain:            fmt.Sprintf("%s-%s", utils.EnvironmentDomain(env, settings), clusterIngress),
+			Replicas:          settings.Router.Replicas,
+			NodePlacement:     settings.Router.NodePlacement,
+			NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{utils.Key: env}},
+		},
+	}
+}
+
+// apply creates the IngressController, or updates it when it changed, and returns its
+// status. The domain of an IngressController cannot be changed, so it is only reported.
+func (r *IngressControllerReconciler) apply(ctx context.Context, desired *operatorv1.IngressController) (EnvironmentStatus, error) {
+	existing := operatorv1.IngressController{}
+	if err := r.Client.Get(ctx, client.ObjectKeyFromObject(desired), &existing); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return EnvironmentStatus{}, err
+		}
+		log.FromContext(ctx).Info("Creating IngressController", "name", desired.Name)
+		if err := r.Client.Create(ctx, desired); err != nil {
+			return EnvironmentStatus{}, err
+		}
+		return EnvironmentStatus{IngressController: desired.Name, Domain: desired.Spec.Domain, Message: "IngressController was created"}, nil
+	}
+
+	if existing.Labels[ManagedByLabel] != ManagedBy {
+		return EnvironmentStatus{}, fmt.Errorf("IngressController %q already exists and is not managed by %s", existing.Name, ManagedBy)
+	}
+
+	if !equality.Semantic.DeepEqual(existing.Spec.Replicas, desired.Spec.Replicas) ||
+		!equality.Semantic.DeepEqual(existing.Spec.NodePlacement, desired.Spec.NodePlacement) ||
+		!equality.Semantic.DeepEqual(existing.Spec.NamespaceSelector, desired.Spec.NamespaceSelector) {
+		existing.Spec.Replicas = desired.Spec.Replicas
+		existing.Spec.NodePlacement = desired.Spec.NodePlacement
+		existing.Spec.NamespaceSelector = desired.Spec.NamespaceSelector
+		log.FromContext(ctx).Info("Updating IngressController", "name", existing.Name)
+		if err := r.Client.Update(ctx, &existing); err != nil {
+			return EnvironmentStatus{}, err
+		}
+	}
+
+	status := EnvironmentStatus{IngressController: existing.Name, Domain: existing.Spec.Domain}
+	if existing.Spec.Domain != desired.Spec.Domain {
+		status.Message = fmt.Sprintf("IngressController serves domain %q instead of %q, and must be recreated", existing.Spec.Domain, desired.Spec.Domain)
+		return status, nil
+	}
+	for _, condition := range existing.Status.Conditions {
+		if condition.Type == operatorv1.OperatorStatusTypeAvailable {
+			status.Available = condition.Status == operatorv1.ConditionTrue
+			status.Message = condition.Message
+		}
+	}
+
+	return status, nil
+}
+
+// collect deletes the managed IngressControllers which are no longer provisioned.
+func (r *IngressControllerReconciler) collect(ctx context.Context, provisioned map[string]bool) error {
+	ingressControllers := operatorv1.IngressControllerList{}
+	if err := r.Client.List(ctx, &ingressControllers, client.InNamespace(utils.IngressOperatorNamespace), client.MatchingLabels{ManagedByLabel: ManagedBy}); err != nil {
+		return err
+	}
+
+	for i := range ingressControllers.Items {
+		ingressController := &ingressControllers.Items[i]
+		if provisioned[ingressController.Name] {
+			continue
+		}
+
+		log.FromContext(ctx).Info("Deleting IngressController of removed environment", "name", ingressController.Name)
+		if err := r.Client.Delete(ctx, ingressController); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// reportStatus sets an annotation with the status of every environment on the
+// configuration ConfigMap, and removes the annotations of removed environments.
+func (r *IngressControllerReconciler) reportStatus(ctx context.Context, statuses map[string]EnvironmentStatus) error {
+	if len(r.ConfigMap.Name) == 0 {
+		return nil
+	}
+
+	configMap := corev1.ConfigMap{}
+	if err := r.Client.Get(ctx, r.ConfigMap, &configMap); err != nil {
+		return err
+	}
+
+	annotations := map[string]string{}
+	for key, value := range configMap.Annotations {
+		if !strings.HasPrefix(key, StatusAnnotationPrefix) {
+			annotations[key] = value
+		}
+	}
+	for env, status := range statuses {
+		value, err := json.Marshal(status)
+		if err != nil {
+			return err
+		}
+		annotations[StatusAnnotationPrefix+env] = string(value)
+	}
+
+	if equality.Semantic.DeepEqual(annotations, configMap.Annotations) || (len(annotations) == 0 && len(configMap.Annotations) == 0) {
+		return nil
+	}
+
+	patch := client.MergeFrom(configMap.DeepCopy())
+	configMap.Annotations = annotations
+	return r.Client.Patch(ctx, &configMap, patch)
+}
+
+// SetupWithManager sets up the reconciler with the Manager. It is reconciled when a
+// managed IngressController, the cluster Ingress, the configuration ConfigMap or the
+// configuration changes.
+func (r *IngressControllerReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	enqueue := enqueueRequest(ingressControllersRequest)
+
+	controllerBuilder := ctrl.NewControllerManagedBy(mgr).
+		Named("ingresscontroller").
+		Watches(&operatorv1.IngressController{}, enqueue, builder.WithPredicates(managedPredicate)).
+		Watches(&configv1.Ingress{}, enqueue)
+	if len(r.ConfigMap.Name) > 0 {
+		controllerBuilder = controllerBuilder.Watches(&corev1.ConfigMap{}, enqueue, builder.WithPredicates(predicate.NewPredicateFuncs(func(obj client.Object) bool {
+			return obj.GetNamespace() == r.ConfigMap.Namespace && obj.GetName() == r.ConfigMap.Name
+		})))
+	}
+
+	if r.Reloads != nil {
+		controllerBuilder = controllerBuilder.WatchesRawSource(source.Channel(r.Reloads, enqueue))
+	}
+
+	return controllerBuilder.Complete(r)
+}
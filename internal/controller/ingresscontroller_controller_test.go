@@ -0,0 +1,151 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/dana-team/env-route-ns-mutator/internal/utils"
+
+	. "github.com/onsi/gomega"
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	testclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const configMapName = "operator-config"
+
+func ingressController(name, env, domain string, replicas int32, managed bool) *operatorv1.IngressController {
+	ingressController := &operatorv1.IngressController{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: utils.IngressOperatorNamespace, Labels: map[string]string{utils.Key: env}},
+		Spec: operatorv1.IngressControllerSpec{
+			Domain:            domain,
+			Replicas:          ptr.To(replicas),
+			NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{utils.Key: env}},
+		},
+	}
+	if managed {
+		ingressController.Labels[ManagedByLabel] = ManagedBy
+	}
+
+	return ingressController
+}
+
+func TestIngressControllerReconciler(t *testing.T) {
+	const settings = "env1:\n  ingressController: router-env1\n  router:\n    replicas: 3\nenv2:\n  ingressController: router-env2\n  router: {}\n"
+
+	available := ingressController("router-env1", "env1", "env1-"+clusterIngressDomain, 3, true)
+	available.Status.Conditions = []operatorv1.OperatorCondition{{Type: operatorv1.OperatorStatusTypeAvailable, Status: operatorv1.ConditionTrue, Message: "The deployment has Available status condition set to True"}}
+
+	tests := []struct {
+		name                       string
+		environments               string
+		objects                    []client.Object
+		expectedIngressControllers map[string]*int32
+		expectedStatus             map[string]EnvironmentStatus
+		failed                     bool
+	}{
+		{
+			name:                       "createIngressControllers",
+			environments:               "env1,env2,env3",
+			expectedIngressControllers: map[string]*int32{"router-env1": ptr.To(int32(3)), "router-env2": nil},
+			expectedStatus: map[string]EnvironmentStatus{
+				"env1": {IngressController: "router-env1", Domain: "env1-" + clusterIngressDomain, Message: "IngressController was created"},
+				"env2": {IngressController: "router-env2", Domain: "env2-" + clusterIngressDomain, Message: "IngressController was created"},
+			},
+		},
+		{
+			name:                       "updateReplicas",
+			environments:               "env1",
+			objects:                    []client.Object{ingressController("router-env1", "env1", "env1-"+clusterIngressDomain, 1, true)},
+			expectedIngressControllers: map[string]*int32{"router-env1": ptr.To(int32(3))},
+			expectedStatus:             map[string]EnvironmentStatus{"env1": {IngressController: "router-env1", Domain: "env1-" + clusterIngressDomain}},
+		},
+		{
+			name:                       "reportAvailable",
+			environments:               "env1",
+			objects:                    []client.Object{available},
+			expectedIngressControllers: map[string]*int32{"router-env1": ptr.To(int32(3))},
+			expectedStatus:             map[string]EnvironmentStatus{"env1": {IngressController: "router-env1", Domain: "env1-" + clusterIngressDomain, Available: true, Message: "The deployment has Available status condition set to True"}},
+		},
+		{
+			name:                       "reportDomainMismatch",
+			environments:               "env1",
+			objects:                    []client.Object{ingressController("router-env1", "env1", "old-"+clusterIngressDomain, 3, true)},
+			expectedIngressControllers: map[string]*int32{"router-env1": ptr.To(int32(3))},
+			expectedStatus:             map[string]EnvironmentStatus{"env1": {IngressController: "router-env1", Domain: "old-" + clusterIngressDomain, Message: `IngressController serves domain "old-apps.ocp-test.os-test.com" instead of "env1-apps.ocp-test.os-test.com", and must be recreated`}},
+		},
+		{
+			name:                       "deleteIngressControllerOfRemovedEnvironment",
+			environments:               "",
+			objects:                    []client.Object{ingressController("router-env1", "env1", "env1-"+clusterIngressDomain, 3, true), ingressController(utils.DefaultIngressController, "", clusterIngressDomain, 2, false)},
+			expectedIngressControllers: map[string]*int32{utils.DefaultIngressController: ptr.To(int32(2))},
+			expectedStatus:             map[string]EnvironmentStatus{},
+		},
+		{
+			name:                       "unmanagedIngressController",
+			environments:               "env2",
+			objects:                    []client.Object{ingressController("router-env2", "env2", "env2-"+clusterIngressDomain, 2, false)},
+			expectedIngressControllers: map[string]*int32{"router-env2": ptr.To(int32(2))},
+			expectedStatus:             map[string]EnvironmentStatus{"env2": {IngressController: "router-env2", Message: `IngressController "router-env2" already exists and is not managed by env-route-ns-mutator`}},
+			failed:                     true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			t.Setenv(utils.Env, tc.environments)
+			t.Setenv(utils.EnvironmentSettingsEnv, settings)
+
+			testScheme := runtime.NewScheme()
+			utilruntime.Must(scheme.AddToScheme(testScheme))
+			utilruntime.Must(configv1.Install(testScheme))
+			utilruntime.Must(operatorv1.Install(testScheme))
+
+			objects := append([]client.Object{
+				&configv1.Ingress{ObjectMeta: metav1.ObjectMeta{Name: "cluster"}, Spec: configv1.IngressSpec{Domain: clusterIngressDomain}},
+				&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: configMapName, Namespace: "system", Annotations: map[string]string{StatusAnnotationPrefix + "env9": "{}"}}},
+			}, tc.objects...)
+			k8sClient := testclient.NewClientBuilder().WithScheme(testScheme).WithObjects(objects...).Build()
+
+			reconciler := &IngressControllerReconciler{Client: k8sClient, ConfigMap: types.NamespacedName{Name: configMapName, Namespace: "system"}}
+			_, err := reconciler.Reconcile(context.Background(), ctrl.Request{})
+			if tc.failed {
+				g.Expect(err).To(HaveOccurred())
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+
+			ingressControllers := operatorv1.IngressControllerList{}
+			g.Expect(k8sClient.List(context.Background(), &ingressControllers)).To(Succeed())
+			actual := map[string]*int32{}
+			for _, ingressController := range ingressControllers.Items {
+				actual[ingressController.Name] = ingressController.Spec.Replicas
+				if ingressController.Labels[ManagedByLabel] == ManagedBy {
+					g.Expect(ingressController.Spec.NamespaceSelector.MatchLabels).To(Equal(map[string]string{utils.Key: ingressController.Labels[utils.Key]}))
+				}
+			}
+			g.Expect(actual).To(Equal(tc.expectedIngressControllers))
+
+			configMap := corev1.ConfigMap{}
+			g.Expect(k8sClient.Get(context.Background(), reconciler.ConfigMap, &configMap)).To(Succeed())
+			statuses := map[string]EnvironmentStatus{}
+			for key, value := range configMap.Annotations {
+				status := EnvironmentStatus{}
+				g.Expect(json.Unmarshal([]byte(value), &status)).To(Succeed())
+				statuses[key[len(StatusAnnotationPrefix):]] = status
+			}
+			g.Expect(statuses).To(Equal(tc.expectedStatus))
+		})
+	}
+}
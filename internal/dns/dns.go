@@ -0,0 +1,126 @@
+package dns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/dana-team/env-route-ns-mutator/internal/utils"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// DefaultTTL is the TTL of the records, in seconds.
+	DefaultTTL = 300
+	// RouterServicePrefix prefixes the name of the LoadBalancer Service which the ingress
+	// operator creates for an IngressController.
+	RouterServicePrefix = "router-"
+
+	RecordTypeA     = "A"
+	RecordTypeCNAME = "CNAME"
+)
+
+// +kubebuilder:rbac:groups="",resources=services,verbs=get;list;watch
+
+// Record is the wildcard DNS record of an environment. The Error of a record is set when
+// the address of the router of its environment cannot be read, in which case the record
+// has no type and no targets.
+type Record struct {
+	Environment string   `json:"environment"`
+	Name        string   `json:"name"`
+	Type        string   `json:"type,omitempty"`
+	Targets     []string `json:"targets"`
+	TTL         int64    `json:"ttl"`
+	Error       string   `json:"error,omitempty"`
+}
+
+// Records returns the wildcard DNS record, *.<env>-<clusterIngressDomain>, that every
+// environment needs, pointing at the load balancer of the router of the environment.
+// Environments with hostname dimensions are skipped, since their hosts are not in the
+// domain of the environment. An environment whose router has no address does not fail the
+// other records; its record is returned with the Error set instead.
+func Records(ctx context.Context, k8sClient client.Client, environments []string, settings map[string]utils.EnvironmentSettings, ttl int64) ([]Record, error) {
+	clusterIngress, err := utils.GetClusterIngressDomain(ctx, k8sClient)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []Record
+	for _, env := range environments {
+		if len(env) == 0 || settings[env].HostnameDimensions != nil {
+			continue
+		}
+
+		record, err := EnvironmentRecord(ctx, k8sClient, env, clusterIngress, settings[env], ttl)
+		if err != nil {
+			record = Record{Environment: env, Name: recordName(env, clusterIngress, settings[env]), TTL: ttl, Error: err.Error()}
+		}
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+// EnvironmentRecord returns the wildcard DNS record of an environment.
+func EnvironmentRecord(ctx context.Context, k8sClient client.Client, env, clusterIngress string, settings utils.EnvironmentSettings, ttl int64) (Record, error) {
+	recordType, targets, err := routerAddress(ctx, k8sClient, utils.IngressControllerName(settings))
+	if err != nil {
+		return Record{}, fmt.Errorf("failed to get the router address of environment %q: %w", env, err)
+	}
+
+	return Record{
+		Environment: env,
+		Name:        recordName(env, clusterIngress, settings),
+		Type:        recordType,
+		Targets:     targets,
+		TTL:         ttl,
+	}, nil
+}
+
+// Failures returns the errors of the records which failed, or nil when every record has
+// an address.
+func Failures(records []Record) error {
+	var errs []error
+	for _, record := range records {
+		if len(record.Error) > 0 {
+			errs = append(errs, errors.New(record.Error))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// recordName returns the wildcard name of the record of an environment.
+func recordName(env, clusterIngress string, settings utils.EnvironmentSettings) string {
+	return fmt.Sprintf("*.%s-%s", utils.EnvironmentDomain(env, settings), clusterIngress)
+}
+
+// routerAddress returns the record type and the targets of the load balancer of the router
+// of an IngressController.
+func routerAddress(ctx context.Context, k8sClient client.Client, ingressController string) (string, []string, error) {
+	service := corev1.Service{}
+	if err := k8sClient.Get(ctx, types.NamespacedName{Name: RouterServicePrefix + ingressController, Namespace: utils.IngressNamespace}, &service); err != nil {
+		return "", nil, err
+	}
+
+	var ips, hostnames []string
+	for _, ingress := range service.Status.LoadBalancer.Ingress {
+		if len(ingress.IP) > 0 && net.ParseIP(ingress.IP) != nil {
+			ips = append(ips, ingress.IP)
+		} else if len(ingress.Hostname) > 0 {
+			hostnames = append(hostnames, ingress.Hostname)
+		}
+	}
+
+	switch {
+	case len(ips) > 0:
+		return RecordTypeA, ips, nil
+	case len(hostnames) > 0:
+		return RecordTypeCNAME, hostnames[:1], nil
+	default:
+		return "", nil, fmt.Errorf("service %s/%s has no load balancer address", utils.IngressNamespace, service.Name)
+	}
+}
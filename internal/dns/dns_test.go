@@ -0,0 +1,155 @@
+package dns
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/dana-team/env-route-ns-mutator/internal/utils"
+
+	. "github.com/onsi/gomega"
+	configv1 "github.com/openshift/api/config/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	testclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	env1                 = "env1"
+	env2                 = "env2"
+	clusterIngressDomain = "apps.ocp-test.os-test.com"
+	routerIP             = "10.0.0.1"
+	routerHostname       = "router.elb.example.com"
+)
+
+func testClient(objects ...runtime.Object) client.Client {
+	testScheme := runtime.NewScheme()
+	utilruntime.Must(scheme.AddToScheme(testScheme))
+	utilruntime.Must(configv1.Install(testScheme))
+
+	objects = append(objects, &configv1.Ingress{ObjectMeta: metav1.ObjectMeta{Name: "cluster"}, Spec: configv1.IngressSpec{Domain: clusterIngressDomain}})
+	return testclient.NewClientBuilder().WithScheme(testScheme).WithRuntimeObjects(objects...).Build()
+}
+
+func routerService(ingressController string, ingress ...corev1.LoadBalancerIngress) *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: RouterServicePrefix + ingressController, Namespace: utils.IngressNamespace},
+		Status:     corev1.ServiceStatus{LoadBalancer: corev1.LoadBalancerStatus{Ingress: ingress}},
+	}
+}
+
+func TestRecords(t *testing.T) {
+	settings := map[string]utils.EnvironmentSettings{
+		env2:         {IngressController: env2},
+		"dimensions": {HostnameDimensions: &utils.HostnameDimensions{Labels: []string{utils.Key, "topology.dana.io/region"}}},
+	}
+
+	tests := []struct {
+		name            string
+		environments    []string
+		objects         []runtime.Object
+		expectedRecords []Record
+		failedRecords   []string
+	}{
+		{name: "recordForLoadBalancerIP", environments: []string{env1}, objects: []runtime.Object{routerService(utils.DefaultIngressController, corev1.LoadBalancerIngress{IP: routerIP})}, expectedRecords: []Record{{Environment: env1, Name: fmt.Sprintf("*.%s-%s", env1, clusterIngressDomain), Type: RecordTypeA, Targets: []string{routerIP}, TTL: DefaultTTL}}},
+		{name: "recordForLoadBalancerHostname", environments: []string{env2}, objects: []runtime.Object{routerService(env2, corev1.LoadBalancerIngress{Hostname: routerHostname})}, expectedRecords: []Record{{Environment: env2, Name: fmt.Sprintf("*.%s-%s", env2, clusterIngressDomain), Type: RecordTypeCNAME, Targets: []string{routerHostname}, TTL: DefaultTTL}}},
+		{name: "emptyEnvironmentsSkipped", environments: []string{""}, expectedRecords: nil},
+		{name: "dimensionedEnvironmentsSkipped", environments: []string{"dimensions"}, expectedRecords: nil},
+		{name: "routerWithoutLoadBalancer", environments: []string{env1}, objects: []runtime.Object{routerService(utils.DefaultIngressController)}, failedRecords: []string{env1}},
+		{name: "routerWithoutService", environments: []string{env2}, failedRecords: []string{env2}},
+		{name: "otherEnvironmentsKept", environments: []string{env1, env2}, objects: []runtime.Object{routerService(utils.DefaultIngressController, corev1.LoadBalancerIngress{IP: routerIP})}, expectedRecords: []Record{{Environment: env1, Name: fmt.Sprintf("*.%s-%s", env1, clusterIngressDomain), Type: RecordTypeA, Targets: []string{routerIP}, TTL: DefaultTTL}}, failedRecords: []string{env2}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			records, err := Records(context.Background(), testClient(tc.objects...), tc.environments, settings, DefaultTTL)
+			g.Expect(err).NotTo(HaveOccurred())
+
+			var succeeded []Record
+			var failed []string
+			for _, record := range records {
+				if len(record.Error) > 0 {
+					g.Expect(record.Name).To(Equal(fmt.Sprintf("*.%s-%s", record.Environment, clusterIngressDomain)))
+					g.Expect(record.Targets).To(BeEmpty())
+					failed = append(failed, record.Environment)
+					continue
+				}
+				succeeded = append(succeeded, record)
+			}
+			g.Expect(succeeded).To(Equal(tc.expectedRecords))
+			g.Expect(failed).To(Equal(tc.failedRecords))
+			g.Expect(Failures(records) != nil).To(Equal(len(tc.failedRecords) > 0))
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	records := []Record{
+		{Environment: env1, Name: fmt.Sprintf("*.%s-%s", env1, clusterIngressDomain), Type: RecordTypeA, Targets: []string{routerIP}, TTL: DefaultTTL},
+		{Environment: env2, Name: fmt.Sprintf("*.%s-%s", env2, clusterIngressDomain), Type: RecordTypeCNAME, Targets: []string{routerHostname}, TTL: DefaultTTL},
+	}
+
+	t.Run("bind", func(t *testing.T) {
+		g := NewWithT(t)
+
+		out := bytes.Buffer{}
+		g.Expect(Write(&out, FormatBIND, records, utils.IngressNamespace)).To(Succeed())
+		g.Expect(out.String()).To(Equal(fmt.Sprintf("; environment env1\n*.env1-%[1]s.\t300\tIN\tA\t%[2]s\n; environment env2\n*.env2-%[1]s.\t300\tIN\tCNAME\t%[3]s.\n", clusterIngressDomain, routerIP, routerHostname)))
+	})
+
+	t.Run("json", func(t *testing.T) {
+		g := NewWithT(t)
+
+		out := bytes.Buffer{}
+		g.Expect(Write(&out, FormatJSON, records, utils.IngressNamespace)).To(Succeed())
+		var written []Record
+		g.Expect(json.Unmarshal(out.Bytes(), &written)).To(Succeed())
+		g.Expect(written).To(Equal(records))
+	})
+
+	t.Run("dnsendpoint", func(t *testing.T) {
+		g := NewWithT(t)
+
+		out := bytes.Buffer{}
+		g.Expect(Write(&out, FormatDNSEndpoint, records, utils.IngressNamespace)).To(Succeed())
+		documents := strings.Split(out.String(), "---\n")
+		g.Expect(documents).To(HaveLen(2))
+
+		endpoint := map[string]interface{}{}
+		g.Expect(yaml.Unmarshal([]byte(documents[0]), &endpoint)).To(Succeed())
+		g.Expect(endpoint).To(HaveKeyWithValue("kind", DNSEndpointKind))
+		g.Expect(endpoint["metadata"]).To(HaveKeyWithValue("name", env1))
+		g.Expect(endpoint["metadata"]).To(HaveKeyWithValue("namespace", utils.IngressNamespace))
+	})
+
+	t.Run("failedRecord", func(t *testing.T) {
+		g := NewWithT(t)
+
+		failed := append([]Record{{Environment: "env3", Name: fmt.Sprintf("*.env3-%s", clusterIngressDomain), TTL: DefaultTTL, Error: "no load balancer address"}}, records...)
+
+		out := bytes.Buffer{}
+		g.Expect(Write(&out, FormatBIND, failed, utils.IngressNamespace)).To(Succeed())
+		g.Expect(out.String()).To(HavePrefix("; environment env3: no load balancer address\n; environment env1\n"))
+
+		out.Reset()
+		g.Expect(Write(&out, FormatDNSEndpoint, failed, utils.IngressNamespace)).To(Succeed())
+		g.Expect(out.String()).To(HavePrefix("# environment env3: no load balancer address\napiVersion: "))
+		g.Expect(strings.Split(out.String(), "---\n")).To(HaveLen(2))
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		g := NewWithT(t)
+
+		g.Expect(Write(&bytes.Buffer{}, Format("csv"), records, utils.IngressNamespace)).NotTo(Succeed())
+	})
+}
@@ -0,0 +1,145 @@
+package dns
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/dana-team/env-route-ns-mutator/internal/utils"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+// Format is the output format of the records.
+type Format string
+
+const (
+	FormatBIND        Format = "bind"
+	FormatJSON        Format = "json"
+	FormatDNSEndpoint Format = "dnsendpoint"
+
+	ExternalDNSGroup      = "externaldns.k8s.io"
+	DNSEndpointAPIVersion = ExternalDNSGroup + "/v1alpha1"
+	DNSEndpointKind       = "DNSEndpoint"
+)
+
+// Write writes the records in the format. DNSEndpoint objects are created in the namespace.
+func Write(w io.Writer, format Format, records []Record, namespace string) error {
+	switch format {
+	case FormatBIND:
+		return writeBIND(w, records)
+	case FormatJSON:
+		return writeJSON(w, records)
+	case FormatDNSEndpoint:
+		return writeDNSEndpoints(w, records, namespace)
+	default:
+		return fmt.Errorf("unknown format %q, use one of %s, %s or %s", format, FormatBIND, FormatJSON, FormatDNSEndpoint)
+	}
+}
+
+// writeBIND writes the records as a BIND zone fragment. Failed records are written as
+// comments.
+func writeBIND(w io.Writer, records []Record) error {
+	for _, record := range records {
+		if len(record.Error) > 0 {
+			if _, err := fmt.Fprintf(w, "; environment %s: %s\n", record.Environment, record.Error); err != nil {
+				return err
+			}
+			continue
+		}
+		if _, err := fmt.Fprintf(w, "; environment %s\n", record.Environment); err != nil {
+			return err
+		}
+		for _, target := range record.Targets {
+			if record.Type == RecordTypeCNAME {
+				target = fqdn(target)
+			}
+			if _, err := fmt.Fprintf(w, "%s\t%d\tIN\t%s\t%s\n", fqdn(record.Name), record.TTL, record.Type, target); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// writeJSON writes the records as a JSON list.
+func writeJSON(w io.Writer, records []Record) error {
+	if records == nil {
+		records = []Record{}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(records)
+}
+
+// writeDNSEndpoints writes the records as ExternalDNS DNSEndpoint objects. Failed records
+// are written as comments.
+func writeDNSEndpoints(w io.Writer, records []Record, namespace string) error {
+	written := 0
+	for _, record := range records {
+		if len(record.Error) > 0 {
+			if _, err := fmt.Fprintf(w, "# environment %s: %s\n", record.Environment, record.Error); err != nil {
+				return err
+			}
+			continue
+		}
+		data, err := yaml.Marshal(DNSEndpoint(record, namespace).Object)
+		if err != nil {
+			return err
+		}
+		if written > 0 {
+			if _, err := io.WriteString(w, "---\n"); err != nil {
+				return err
+			}
+		}
+		if _, err := w.Write(data); err != nil {
+			return err
+		}
+		written++
+	}
+
+	return nil
+}
+
+// DNSEndpoint returns the ExternalDNS DNSEndpoint object of a record. It is named after
+// the environment of the record and labeled with it.
+func DNSEndpoint(record Record, namespace string) *unstructured.Unstructured {
+	targets := make([]interface{}, 0, len(record.Targets))
+	for _, target := range record.Targets {
+		targets = append(targets, target)
+	}
+
+	endpoint := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": DNSEndpointAPIVersion,
+		"kind":       DNSEndpointKind,
+		"metadata": map[string]interface{}{
+			"name":      record.Environment,
+			"namespace": namespace,
+		},
+		"spec": map[string]interface{}{
+			"endpoints": []interface{}{
+				map[string]interface{}{
+					"dnsName":    record.Name,
+					"recordType": record.Type,
+					"recordTTL":  record.TTL,
+					"targets":    targets,
+				},
+			},
+		},
+	}}
+	endpoint.SetLabels(map[string]string{utils.Key: record.Environment})
+
+	return endpoint
+}
+
+// fqdn returns the fully qualified form of a DNS name.
+func fqdn(name string) string {
+	if strings.HasSuffix(name, ".") {
+		return name
+	}
+
+	return name + "."
+}
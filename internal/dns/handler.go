@@ -0,0 +1,66 @@
+package dns
+
+import (
+	"bytes"
+	"net/http"
+
+	"github.com/dana-team/env-route-ns-mutator/internal/config"
+	"github.com/dana-team/env-route-ns-mutator/internal/utils"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// Handler serves the DNS records of the environments. The format is selected with the
+// format query parameter and defaults to JSON, and DNSEndpoint objects are created in the
+// namespace query parameter, which defaults to the namespace of the routers. An environment
+// whose router has no address is reported in its record, without failing the response.
+type Handler struct {
+	Client client.Client
+}
+
+var contentTypes = map[Format]string{
+	FormatBIND:        "text/plain",
+	FormatJSON:        "application/json",
+	FormatDNSEndpoint: "application/yaml",
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	logger := log.FromContext(r.Context()).WithName("DNSRecords")
+
+	format := Format(r.URL.Query().Get("format"))
+	if len(format) == 0 {
+		format = FormatJSON
+	}
+	namespace := r.URL.Query().Get("namespace")
+	if len(namespace) == 0 {
+		namespace = utils.IngressNamespace
+	}
+
+	cfg, err := config.Current()
+	if err != nil {
+		logger.Error(err, "failed to get configuration")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	records, err := Records(r.Context(), h.Client, cfg.Environments, cfg.EnvironmentSettings, DefaultTTL)
+	if err != nil {
+		logger.Error(err, "failed to get dns records")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	for _, record := range records {
+		if len(record.Error) > 0 {
+			logger.Info("Skipping dns record of environment", "environment", record.Environment, "error", record.Error)
+		}
+	}
+
+	body := bytes.Buffer{}
+	if err := Write(&body, format, records, namespace); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentTypes[format])
+	_, _ = w.Write(body.Bytes())
+}
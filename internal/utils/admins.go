@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"os"
+	"strings"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+)
+
+const (
+	EnvironmentAdminGroupsEnv = "environmentAdminGroups"
+	// clusterAdminGroup is always allowed to set the environment label.
+	clusterAdminGroup = "system:masters"
+)
+
+// GetEnvironmentAdminGroups retrieves the groups allowed to set the environment label of a
+// Namespace from a comma-separated environment variable. Group names may be glob patterns.
+func GetEnvironmentAdminGroups() []string {
+	return EnvironmentAdminGroups(strings.Split(os.Getenv(EnvironmentAdminGroupsEnv), ","))
+}
+
+// EnvironmentAdminGroups returns the cluster admin group followed by the non-empty groups.
+func EnvironmentAdminGroups(configured []string) []string {
+	groups := []string{clusterAdminGroup}
+	for _, group := range configured {
+		if group = strings.TrimSpace(group); len(group) > 0 {
+			groups = append(groups, group)
+		}
+	}
+
+	return groups
+}
+
+// MatchEnvironmentAdmin returns the first group of the requester which is one of the
+// environment admin groups.
+func MatchEnvironmentAdmin(adminGroups []string, userInfo authenticationv1.UserInfo) (string, bool) {
+	for _, pattern := range adminGroups {
+		for _, group := range userInfo.Groups {
+			if len(pattern) > 0 && globMatch(pattern, group) {
+				return group, true
+			}
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"os"
+	"strings"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+)
+
+const (
+	BypassLabelEnv = "bypassLabel"
+	BypassUsersEnv = "bypassUsers"
+	// DefaultBypassLabel is the namespace label which bypasses the mutation when no other
+	// label is configured.
+	DefaultBypassLabel = "haproxy.router.dana.io/bypass-env-mutation"
+)
+
+// Bypass holds the namespaces and users whose objects are neither mutated nor validated.
+// A namespace bypasses the mutation when its bypass label is set to "true".
+type Bypass struct {
+	// Label is the key of the bypass label. Defaults to DefaultBypassLabel.
+	Label string `json:"label,omitempty"`
+	// Users are the usernames, or glob patterns of usernames, whose requests bypass the
+	// mutation.
+	Users []string `json:"users,omitempty"`
+}
+
+// GetBypass retrieves the bypass label and the comma-separated bypass users from
+// environment variables.
+func GetBypass() Bypass {
+	bypass := Bypass{Label: strings.TrimSpace(os.Getenv(BypassLabelEnv))}
+	for _, user := range strings.Split(os.Getenv(BypassUsersEnv), ",") {
+		if user = strings.TrimSpace(user); len(user) > 0 {
+			bypass.Users = append(bypass.Users, user)
+		}
+	}
+
+	return bypass
+}
+
+// LabelKey returns the key of the bypass label.
+func (b Bypass) LabelKey() string {
+	if len(b.Label) == 0 {
+		return DefaultBypassLabel
+	}
+
+	return b.Label
+}
+
+// BypassesNamespace checks if the namespace has the bypass label.
+func (b Bypass) BypassesNamespace(labels map[string]string) bool {
+	return labels[b.LabelKey()] == "true"
+}
+
+// MatchUser returns the first bypass user pattern matching the requester.
+func (b Bypass) MatchUser(userInfo authenticationv1.UserInfo) (string, bool) {
+	for _, pattern := range b.Users {
+		if len(pattern) > 0 && globMatch(pattern, userInfo.Username) {
+			return pattern, true
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,170 @@
+package utils
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// DefaultDimensionSeparator joins the hostname dimensions when no separator is set.
+const DefaultDimensionSeparator = "-"
+
+// HostnameDimensions compose the environment part of hosts from Namespace labels, e.g.
+// <env>-<region> for the environment and region labels. The DNS records and routers of the
+// composed domains are not managed, so an environment with dimensions cannot provision a
+// router, and gets no DNS record.
+type HostnameDimensions struct {
+	// Labels are the keys of the Namespace labels whose values are the dimensions, in order.
+	// The environment label stands for the domain of the environment.
+	Labels []string `json:"labels"`
+	// Separator joins the dimensions. Defaults to -.
+	Separator string `json:"separator,omitempty"`
+}
+
+// EnvironmentHostDomain returns the environment part of the hosts of a Namespace. It is
+// the domain of the environment, unless the environment has hostname dimensions, in which
+// case every dimension label must be set on the Namespace.
+func EnvironmentHostDomain(env string, settings EnvironmentSettings, namespaceLabels map[string]string) (string, error) {
+	dimensions := settings.HostnameDimensions
+	if dimensions == nil {
+		return EnvironmentDomain(env, settings), nil
+	}
+
+	var values, missing []string
+	for _, key := range dimensions.Labels {
+		if key == Key {
+			values = append(values, EnvironmentDomain(env, settings))
+			continue
+		}
+		value, ok := namespaceLabels[key]
+		if !ok || len(value) == 0 {
+			missing = append(missing, key)
+			continue
+		}
+		values = append(values, value)
+	}
+	if len(missing) > 0 {
+		return "", fmt.Errorf("namespace is missing the hostname dimension labels %s of environment %q", strings.Join(missing, ", "), env)
+	}
+
+	separator := dimensions.Separator
+	if len(separator) == 0 {
+		separator = DefaultDimensionSeparator
+	}
+
+	domain := strings.Join(values, separator)
+	if errs := validation.IsDNS1123Subdomain(domain); len(errs) > 0 {
+		return "", fmt.Errorf("hostname dimensions of environment %q compose an invalid domain %q: %s", env, domain, strings.Join(errs, ", "))
+	}
+
+	return domain, nil
+}
+
+// domainPattern describes the domains an environment composes: its domain, preceded by
+// dimension values when before is set and followed by dimension values when after is set,
+// joined by the separator. Dimension values are arbitrary, and may contain the separator.
+type domainPattern struct {
+	domain    string
+	separator string
+	before    bool
+	after     bool
+}
+
+// environmentDomainPattern returns the pattern of the domains an environment composes.
+func environmentDomainPattern(env string, settings EnvironmentSettings) domainPattern {
+	pattern := domainPattern{domain: EnvironmentDomain(env, settings)}
+	if dimensions := settings.HostnameDimensions; dimensions != nil {
+		index := slices.Index(dimensions.Labels, Key)
+		pattern.before = index > 0
+		pattern.after = index >= 0 && index < len(dimensions.Labels)-1
+		pattern.separator = dimensions.Separator
+		if len(pattern.separator) == 0 {
+			pattern.separator = DefaultDimensionSeparator
+		}
+	}
+
+	return pattern
+}
+
+// core returns the domain with the separators joining it to the dimension values.
+func (p domainPattern) core() string {
+	core := p.domain
+	if p.before {
+		core = p.separator + core
+	}
+	if p.after {
+		core += p.separator
+	}
+
+	return core
+}
+
+// composes checks if the pattern composes a domain for some dimension values.
+func (p domainPattern) composes(domain string) bool {
+	core := p.core()
+	switch {
+	case p.before && p.after:
+		return len(domain) > 2 && strings.Contains(domain[1:len(domain)-1], core)
+	case p.before:
+		return len(domain) > len(core) && strings.HasSuffix(domain, core)
+	case p.after:
+		return len(domain) > len(core) && strings.HasPrefix(domain, core)
+	default:
+		return domain == core
+	}
+}
+
+// DomainsOverlap checks if two environments can compose the same domain, so that their
+// hosts could collide. Two environments with hostname dimensions only cannot overlap when
+// both start, or both end, with their distinct domains.
+func DomainsOverlap(env string, settings EnvironmentSettings, otherEnv string, otherSettings EnvironmentSettings) bool {
+	pattern := environmentDomainPattern(env, settings)
+	other := environmentDomainPattern(otherEnv, otherSettings)
+
+	exact, otherExact := !pattern.before && !pattern.after, !other.before && !other.after
+	switch {
+	case exact:
+		return other.composes(pattern.domain)
+	case otherExact:
+		return pattern.composes(other.domain)
+	case !pattern.before && !other.before:
+		return strings.HasPrefix(pattern.core(), other.core()) || strings.HasPrefix(other.core(), pattern.core())
+	case !pattern.after && !other.after:
+		return strings.HasSuffix(pattern.core(), other.core()) || strings.HasSuffix(other.core(), pattern.core())
+	default:
+		return true
+	}
+}
+
+// validateHostnameDimensions validates the hostname dimensions of an environment.
+func validateHostnameDimensions(dimensions *HostnameDimensions, path *field.Path) field.ErrorList {
+	if dimensions == nil {
+		return nil
+	}
+
+	var errs field.ErrorList
+	labelsPath := path.Child("labels")
+	if len(dimensions.Labels) == 0 {
+		errs = append(errs, field.Required(labelsPath, "no labels are set"))
+	} else if !slices.Contains(dimensions.Labels, Key) {
+		errs = append(errs, field.Invalid(labelsPath, dimensions.Labels, fmt.Sprintf("the %q label must be one of the labels, so that hosts of different environments do not collide", Key)))
+	}
+	seen := map[string]bool{}
+	for i, key := range dimensions.Labels {
+		for _, msg := range validation.IsQualifiedName(key) {
+			errs = append(errs, field.Invalid(labelsPath.Index(i), key, msg))
+		}
+		if seen[key] {
+			errs = append(errs, field.Duplicate(labelsPath.Index(i), key))
+		}
+		seen[key] = true
+	}
+	if strings.Trim(dimensions.Separator, "-.") != "" {
+		errs = append(errs, field.Invalid(path.Child("separator"), dimensions.Separator, "must only consist of '-' and '.'"))
+	}
+
+	return errs
+}
@@ -0,0 +1,99 @@
+package utils
+
+import (
+	"os"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	ExcludedNamespacesEnv      = "excludedNamespaces"
+	ExcludedNamespaceLabelsEnv = "excludedNamespaceLabels"
+	ExcludedObjectLabelsEnv    = "excludedObjectLabels"
+)
+
+// Exclusions holds the namespaces and objects that are never mutated.
+// A namespace is excluded when its name matches one of the Namespaces patterns
+// or when its labels match any single requirement of NamespaceLabels.
+// An object is excluded when its labels match any single requirement of ObjectLabels.
+type Exclusions struct {
+	Namespaces      []string
+	NamespaceLabels labels.Requirements
+	ObjectLabels    labels.Requirements
+}
+
+// GetExclusions retrieves the exclusion list from environment variables.
+// excludedNamespaces is a comma-separated list of namespace names or glob patterns,
+// while excludedNamespaceLabels and excludedObjectLabels are label selectors whose
+// requirements are each evaluated on their own.
+func GetExclusions() (Exclusions, error) {
+	exclusions := Exclusions{}
+
+	for _, pattern := range strings.Split(os.Getenv(ExcludedNamespacesEnv), ",") {
+		if pattern = strings.TrimSpace(pattern); len(pattern) > 0 {
+			exclusions.Namespaces = append(exclusions.Namespaces, pattern)
+		}
+	}
+
+	namespaceLabels, err := parseRequirements(os.Getenv(ExcludedNamespaceLabelsEnv))
+	if err != nil {
+		return Exclusions{}, err
+	}
+	exclusions.NamespaceLabels = namespaceLabels
+
+	objectLabels, err := parseRequirements(os.Getenv(ExcludedObjectLabelsEnv))
+	if err != nil {
+		return Exclusions{}, err
+	}
+	exclusions.ObjectLabels = objectLabels
+
+	return exclusions, nil
+}
+
+// ExcludesNamespaceName checks if the namespace name matches one of the excluded names or patterns.
+func (e Exclusions) ExcludesNamespaceName(name string) (string, bool) {
+	for _, pattern := range e.Namespaces {
+		if globMatch(pattern, name) {
+			return pattern, true
+		}
+	}
+
+	return "", false
+}
+
+// ExcludesNamespaceLabels checks if the namespace labels match one of the excluded label requirements.
+func (e Exclusions) ExcludesNamespaceLabels(nsLabels map[string]string) (string, bool) {
+	return matchesAny(e.NamespaceLabels, nsLabels)
+}
+
+// ExcludesObjectLabels checks if the object labels match one of the excluded label requirements.
+func (e Exclusions) ExcludesObjectLabels(objectLabels map[string]string) (string, bool) {
+	return matchesAny(e.ObjectLabels, objectLabels)
+}
+
+// parseRequirements parses a label selector and returns its requirements.
+func parseRequirements(selector string) (labels.Requirements, error) {
+	if len(strings.TrimSpace(selector)) == 0 {
+		return nil, nil
+	}
+
+	parsed, err := labels.Parse(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	requirements, _ := parsed.Requirements()
+	return requirements, nil
+}
+
+// matchesAny returns the first requirement matching the given labels.
+func matchesAny(requirements labels.Requirements, objectLabels map[string]string) (string, bool) {
+	for _, requirement := range requirements {
+		if requirement.Matches(labels.Set(objectLabels)) {
+			return requirement.String(), true
+		}
+	}
+
+	return "", false
+}
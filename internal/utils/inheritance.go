@@ -0,0 +1,141 @@
+package utils
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ResolveEnvironmentSettings returns the settings of every environment merged with the
+// settings of its ancestors, and sets their Chain. It fails when the parents form a cycle.
+func ResolveEnvironmentSettings(settings map[string]EnvironmentSettings) (map[string]EnvironmentSettings, error) {
+	resolved := make(map[string]EnvironmentSettings, len(settings))
+	for env := range settings {
+		chain, err := inheritanceChain(env, settings)
+		if err != nil {
+			return nil, err
+		}
+
+		envSettings := EnvironmentSettings{}
+		for i := len(chain) - 1; i >= 0; i-- {
+			envSettings = inherit(settings[chain[i]], envSettings)
+		}
+		envSettings.IngressController = settings[env].IngressController
+		envSettings.Router = settings[env].Router
+		envSettings.Chain = chain
+		resolved[env] = envSettings
+	}
+
+	return resolved, nil
+}
+
+// inheritanceChain returns the environment followed by its ancestors.
+func inheritanceChain(env string, settings map[string]EnvironmentSettings) ([]string, error) {
+	chain := []string{env}
+	visited := map[string]bool{env: true}
+	for parent := settings[env].Parent; len(parent) > 0; parent = settings[parent].Parent {
+		chain = append(chain, parent)
+		if visited[parent] {
+			return nil, fmt.Errorf("environment %q has an inheritance cycle: %s", env, strings.Join(chain, " -> "))
+		}
+		visited[parent] = true
+	}
+
+	return chain, nil
+}
+
+// inherit returns the settings of a child merged with the resolved settings of its parent.
+// The maps are merged, with the values of the child taking precedence, and every other
+// setting of the parent is used only when the child does not set it.
+func inherit(child, parent EnvironmentSettings) EnvironmentSettings {
+	merged := child
+
+	if len(merged.Domain) == 0 {
+		merged.Domain = parent.Domain
+	}
+	if len(merged.SubdomainPolicy) == 0 {
+		merged.SubdomainPolicy = parent.SubdomainPolicy
+	}
+	if merged.HostnameDimensions == nil {
+		merged.HostnameDimensions = parent.HostnameDimensions
+	}
+	if merged.Gateway == nil {
+		merged.Gateway = parent.Gateway
+	}
+	if merged.IssuerRef == nil {
+		merged.IssuerRef = parent.IssuerRef
+	}
+	merged.IssuerAnnotations = mergeMaps(parent.IssuerAnnotations, child.IssuerAnnotations)
+	if merged.RouteTLS == nil {
+		merged.RouteTLS = parent.RouteTLS
+	}
+	merged.RouteAnnotations = mergeMaps(parent.RouteAnnotations, child.RouteAnnotations)
+	merged.EnforcedRouteAnnotations = mergeKeys(parent.EnforcedRouteAnnotations, child.EnforcedRouteAnnotations)
+	if merged.IngressClass == nil {
+		merged.IngressClass = parent.IngressClass
+	}
+	merged.Namespace = inheritNamespaceBundle(child.Namespace, parent.Namespace)
+	if len(merged.Tolerations) == 0 {
+		merged.Tolerations = parent.Tolerations
+	}
+	if len(merged.NodeSelector) == 0 {
+		merged.NodeSelector = parent.NodeSelector
+	}
+	if len(merged.HostCollision) == 0 {
+		merged.HostCollision = parent.HostCollision
+	}
+	if len(merged.CertificateCoverage) == 0 {
+		merged.CertificateCoverage = parent.CertificateCoverage
+	}
+
+	return merged
+}
+
+// inheritNamespaceBundle merges the namespace bundle of a child with the bundle of its parent.
+func inheritNamespaceBundle(child, parent *NamespaceBundle) *NamespaceBundle {
+	switch {
+	case child == nil:
+		return parent
+	case parent == nil:
+		return child
+	}
+
+	return &NamespaceBundle{
+		Labels:      mergeMaps(parent.Labels, child.Labels),
+		Annotations: mergeMaps(parent.Annotations, child.Annotations),
+		Enforced:    mergeKeys(parent.Enforced, child.Enforced),
+	}
+}
+
+// mergeMaps returns a new map with the values of the parent overridden by those of the child.
+func mergeMaps(parent, child map[string]string) map[string]string {
+	if len(parent) == 0 && len(child) == 0 {
+		return nil
+	}
+
+	merged := make(map[string]string, len(parent)+len(child))
+	for key, value := range parent {
+		merged[key] = value
+	}
+	for key, value := range child {
+		merged[key] = value
+	}
+
+	return merged
+}
+
+// mergeKeys returns the keys of the parent followed by the keys of the child which the
+// parent does not have.
+func mergeKeys(parent, child []string) []string {
+	merged := append([]string{}, parent...)
+	for _, key := range child {
+		if !slices.Contains(parent, key) {
+			merged = append(merged, key)
+		}
+	}
+
+	if len(merged) == 0 {
+		return nil
+	}
+	return merged
+}
@@ -0,0 +1,139 @@
+package utils
+
+import (
+	"os"
+	"path"
+	"strings"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+const PlatformRulesEnv = "platformRules"
+
+// PlatformRule exempts objects created or managed by platform controllers from mutation.
+// All the non-empty fields of a rule must match for the rule to match, and every
+// field accepts glob patterns. The ownerReferences and managedFields of an object are set
+// by the client, so a rule matching on them must also match on the requesting user or
+// group, unless it trusts the client.
+type PlatformRule struct {
+	// Name identifies the rule in logs and audit annotations.
+	Name string `json:"name"`
+	// OwnerAPIVersion matches the apiVersion of one of the object ownerReferences.
+	OwnerAPIVersion string `json:"ownerAPIVersion,omitempty"`
+	// OwnerKind matches the kind of one of the object ownerReferences.
+	OwnerKind string `json:"ownerKind,omitempty"`
+	// Manager matches one of the managers in the object managedFields.
+	Manager string `json:"manager,omitempty"`
+	// Username matches the name of the requesting user or service account.
+	Username string `json:"username,omitempty"`
+	// Group matches one of the groups of the requesting user.
+	Group string `json:"group,omitempty"`
+	// TrustClientFields allows the rule to match on OwnerAPIVersion, OwnerKind or Manager
+	// alone, which anyone allowed to create the object can forge.
+	TrustClientFields bool `json:"trustClientFields,omitempty"`
+}
+
+// DefaultPlatformRules are the rules for well-known OpenShift controllers. They only match
+// on the authenticated requester, which the client cannot forge.
+var DefaultPlatformRules = []PlatformRule{
+	{Name: "openshift-service-accounts", Username: "system:serviceaccount:openshift-*"},
+	{Name: "knative-ingress", Username: "system:serviceaccount:knative-serving-ingress:*"},
+	{Name: "knative-serving", Username: "system:serviceaccount:knative-serving:*"},
+}
+
+// GetPlatformRules returns the default platform rules followed by the rules
+// retrieved from a YAML list in an environment variable.
+func GetPlatformRules() ([]PlatformRule, error) {
+	rules := append([]PlatformRule{}, DefaultPlatformRules...)
+
+	value := os.Getenv(PlatformRulesEnv)
+	if len(strings.TrimSpace(value)) == 0 {
+		return rules, nil
+	}
+
+	var configured []PlatformRule
+	if err := yaml.Unmarshal([]byte(value), &configured); err != nil {
+		return nil, err
+	}
+
+	return append(rules, configured...), nil
+}
+
+// MatchPlatformRule returns the first rule matching the object or the requesting user.
+func MatchPlatformRule(rules []PlatformRule, obj metav1.Object, userInfo authenticationv1.UserInfo) (PlatformRule, bool) {
+	for _, rule := range rules {
+		if rule.matches(obj, userInfo) {
+			return rule, true
+		}
+	}
+
+	return PlatformRule{}, false
+}
+
+// MatchesClientFieldsOnly checks if the rule matches on fields set by the client, without
+// matching on the requesting user or group.
+func (r PlatformRule) MatchesClientFieldsOnly() bool {
+	return (len(r.OwnerAPIVersion) > 0 || len(r.OwnerKind) > 0 || len(r.Manager) > 0) &&
+		len(r.Username) == 0 && len(r.Group) == 0
+}
+
+// matches checks that every non-empty field of the rule matches.
+func (r PlatformRule) matches(obj metav1.Object, userInfo authenticationv1.UserInfo) bool {
+	if len(r.OwnerAPIVersion) == 0 && len(r.OwnerKind) == 0 && len(r.Manager) == 0 &&
+		len(r.Username) == 0 && len(r.Group) == 0 {
+		return false
+	}
+
+	if len(r.OwnerAPIVersion) > 0 || len(r.OwnerKind) > 0 {
+		owned := false
+		for _, owner := range obj.GetOwnerReferences() {
+			if globMatch(r.OwnerAPIVersion, owner.APIVersion) && globMatch(r.OwnerKind, owner.Kind) {
+				owned = true
+				break
+			}
+		}
+		if !owned {
+			return false
+		}
+	}
+
+	if len(r.Manager) > 0 {
+		managed := false
+		for _, entry := range obj.GetManagedFields() {
+			if globMatch(r.Manager, entry.Manager) {
+				managed = true
+				break
+			}
+		}
+		if !managed {
+			return false
+		}
+	}
+
+	if !globMatch(r.Username, userInfo.Username) {
+		return false
+	}
+
+	if len(r.Group) > 0 {
+		for _, group := range userInfo.Groups {
+			if globMatch(r.Group, group) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return true
+}
+
+// globMatch checks if the value matches the glob pattern. An empty pattern matches everything.
+func globMatch(pattern, value string) bool {
+	if len(pattern) == 0 {
+		return true
+	}
+
+	matched, err := path.Match(pattern, value)
+	return err == nil && matched
+}
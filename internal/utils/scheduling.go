@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"encoding/json"
+	"regexp"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// legacyTolerationKey matches the unquoted toleration key of the legacy defaultTolerations
+// format, e.g. [{"operator": "Exists", "effect": "NoSchedule", "key": env1}].
+var legacyTolerationKey = regexp.MustCompile(`("key":\s*)([^"\s,}\]][^,}\]]*?)(\s*[,}])`)
+
+// EnvironmentTolerations returns the tolerations of the Namespaces in an environment,
+// which default to a NoSchedule toleration of the environment name.
+func EnvironmentTolerations(env string, settings EnvironmentSettings) []corev1.Toleration {
+	if len(settings.Tolerations) > 0 {
+		return settings.Tolerations
+	}
+
+	return []corev1.Toleration{{Key: env, Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule}}
+}
+
+// ParseTolerations parses the value of a defaultTolerations annotation. Both JSON and the
+// legacy format, in which the toleration key is not quoted, are accepted.
+func ParseTolerations(value string) ([]corev1.Toleration, error) {
+	var tolerations []corev1.Toleration
+	if err := json.Unmarshal([]byte(value), &tolerations); err == nil {
+		return tolerations, nil
+	}
+
+	quoted := legacyTolerationKey.ReplaceAllString(value, `$1"$2"$3`)
+	if err := json.Unmarshal([]byte(quoted), &tolerations); err != nil {
+		return nil, err
+	}
+
+	return tolerations, nil
+}
+
+// FormatTolerations formats tolerations as the value of a defaultTolerations annotation.
+func FormatTolerations(tolerations []corev1.Toleration) (string, error) {
+	value, err := json.Marshal(tolerations)
+	if err != nil {
+		return "", err
+	}
+
+	return string(value), nil
+}
+
+// ContainsTolerations checks if all the required tolerations are in the tolerations.
+func ContainsTolerations(tolerations, required []corev1.Toleration) bool {
+	for i := range required {
+		found := false
+		for j := range tolerations {
+			if tolerations[j].MatchToleration(&required[i]) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,289 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	routev1 "github.com/openshift/api/route/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	EnvironmentSettingsEnv   = "environmentSettings"
+	IngressOperatorNamespace = "openshift-ingress-operator"
+	IngressNamespace         = "openshift-ingress"
+	DefaultIngressController = "default"
+)
+
+// SubdomainPolicy defines how Routes using spec.subdomain are handled in an environment.
+type SubdomainPolicy string
+
+const (
+	// SubdomainPolicyRewrite converts the subdomain into a fully qualified environment host.
+	SubdomainPolicyRewrite SubdomainPolicy = "Rewrite"
+	// SubdomainPolicyShard leaves the subdomain as is, for the router shard of the
+	// environment to combine with its own domain.
+	SubdomainPolicyShard SubdomainPolicy = "Shard"
+)
+
+// HostCollisionPolicy defines how a host which is already held by a Route or Ingress in
+// another namespace is handled in an environment.
+type HostCollisionPolicy string
+
+const (
+	// HostCollisionWarn admits the host with an admission warning.
+	HostCollisionWarn HostCollisionPolicy = "Warn"
+	// HostCollisionDeny rejects the object.
+	HostCollisionDeny HostCollisionPolicy = "Deny"
+	// HostCollisionDisambiguate suffixes the first label of the host with a hash of the namespace.
+	HostCollisionDisambiguate HostCollisionPolicy = "Disambiguate"
+)
+
+// CertificateCoveragePolicy defines how hosts which are not covered by the default
+// certificate of the router of an environment are handled.
+type CertificateCoveragePolicy string
+
+const (
+	// CertificateCoverageWarn admits the host with an admission warning.
+	CertificateCoverageWarn CertificateCoveragePolicy = "Warn"
+	// CertificateCoverageDeny rejects the object.
+	CertificateCoverageDeny CertificateCoveragePolicy = "Deny"
+)
+
+// GatewayRef references the Gateway API Gateway of an environment.
+type GatewayRef struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// IssuerRef references the cert-manager issuer of an environment.
+type IssuerRef struct {
+	Name  string `json:"name"`
+	Kind  string `json:"kind,omitempty"`
+	Group string `json:"group,omitempty"`
+}
+
+// IngressClass holds the IngressClass of the Ingresses in an environment.
+type IngressClass struct {
+	Name string `json:"name"`
+	// Strict overrides the class that an Ingress is created with, instead of only
+	// setting a class on Ingresses which do not have one.
+	Strict bool `json:"strict,omitempty"`
+}
+
+// NamespaceBundle holds the labels and annotations of the Namespaces in an environment.
+type NamespaceBundle struct {
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// Enforced are the keys of Labels and Annotations whose value overrides the value
+	// set on the Namespace, so that later edits are reverted.
+	Enforced []string `json:"enforced,omitempty"`
+}
+
+// RouteTLS holds the TLS policy of Routes in an environment.
+type RouteTLS struct {
+	// Termination is the termination that Routes without spec.tls get.
+	Termination routev1.TLSTerminationType `json:"termination,omitempty"`
+	// InsecureEdgeTerminationPolicy is the insecureEdgeTerminationPolicy that Routes
+	// without spec.tls get.
+	InsecureEdgeTerminationPolicy routev1.InsecureEdgeTerminationPolicyType `json:"insecureEdgeTerminationPolicy,omitempty"`
+	// Required rejects Routes which do not set spec.tls or which allow insecure traffic.
+	Required bool `json:"required,omitempty"`
+}
+
+// Router holds the IngressController which is provisioned for an environment.
+type Router struct {
+	// Replicas is the number of router replicas. Defaults to the ingress operator default.
+	Replicas *int32 `json:"replicas,omitempty"`
+	// NodePlacement is the placement of the router pods.
+	NodePlacement *operatorv1.NodePlacement `json:"nodePlacement,omitempty"`
+}
+
+// EnvironmentSettings holds the settings of a single environment.
+type EnvironmentSettings struct {
+	// Parent is the environment whose settings are inherited. Every setting which is not set
+	// is inherited, except for the IngressController and Router of the environment.
+	Parent string `json:"parent,omitempty"`
+	// Domain is the environment part of hosts, <domain>-<clusterIngressDomain>. Defaults to
+	// the environment name. Environments share a domain only when they do not publish
+	// conflicting DNS records for it.
+	Domain string `json:"domain,omitempty"`
+	// HostnameDimensions compose the environment part of hosts from the labels of the
+	// Namespace, instead of the domain alone.
+	HostnameDimensions *HostnameDimensions `json:"hostnameDimensions,omitempty"`
+	// SubdomainPolicy defines how Routes using spec.subdomain are handled. Defaults to Rewrite.
+	SubdomainPolicy SubdomainPolicy `json:"subdomainPolicy,omitempty"`
+	// Gateway is the Gateway that the parentRefs of Gateway API routes are rewritten to.
+	Gateway *GatewayRef `json:"gateway,omitempty"`
+	// IssuerRef is the issuer that cert-manager Certificates are issued by.
+	IssuerRef *IssuerRef `json:"issuerRef,omitempty"`
+	// IssuerAnnotations are the cert-manager annotations that Routes and Ingresses get
+	// when they do not set them, e.g. cert-manager.io/cluster-issuer.
+	IssuerAnnotations map[string]string `json:"issuerAnnotations,omitempty"`
+	// RouteTLS is the TLS policy of Routes.
+	RouteTLS *RouteTLS `json:"routeTLS,omitempty"`
+	// RouteAnnotations are the router annotations that Routes get when they do not set
+	// them, e.g. haproxy.router.openshift.io/timeout.
+	RouteAnnotations map[string]string `json:"routeAnnotations,omitempty"`
+	// EnforcedRouteAnnotations are the keys of RouteAnnotations whose value overrides
+	// the value set on the Route.
+	EnforcedRouteAnnotations []string `json:"enforcedRouteAnnotations,omitempty"`
+	// IngressClass is the IngressClass that Ingresses are served by.
+	IngressClass *IngressClass `json:"ingressClass,omitempty"`
+	// Namespace is the bundle of labels and annotations that Namespaces get.
+	Namespace *NamespaceBundle `json:"namespace,omitempty"`
+	// Tolerations are the defaultTolerations of the Namespaces. Defaults to a NoSchedule
+	// toleration of the environment name.
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+	// NodeSelector is the openshift.io/node-selector of the Namespaces.
+	NodeSelector string `json:"nodeSelector,omitempty"`
+	// HostCollision defines how hosts held by Routes or Ingresses in other namespaces are
+	// handled. Defaults to Warn.
+	HostCollision HostCollisionPolicy `json:"hostCollision,omitempty"`
+	// IngressController is the name of the IngressController which serves the environment.
+	// Defaults to default.
+	IngressController string `json:"ingressController,omitempty"`
+	// CertificateCoverage defines how hosts which are not covered by the default certificate
+	// of the IngressController are handled. The check is disabled when empty.
+	CertificateCoverage CertificateCoveragePolicy `json:"certificateCoverage,omitempty"`
+	// Router provisions the IngressController of the environment, when the provisioning
+	// controller is enabled.
+	Router *Router `json:"router,omitempty"`
+
+	// Chain is the environment followed by the environments its settings are inherited
+	// from, set when the settings are resolved.
+	Chain []string `json:"-"`
+}
+
+// EnvironmentDomain returns the environment part of the hosts of an environment.
+func EnvironmentDomain(env string, settings EnvironmentSettings) string {
+	if len(settings.Domain) == 0 {
+		return env
+	}
+
+	return settings.Domain
+}
+
+// IngressControllerName returns the name of the IngressController which serves an environment.
+func IngressControllerName(settings EnvironmentSettings) string {
+	if len(settings.IngressController) == 0 {
+		return DefaultIngressController
+	}
+
+	return settings.IngressController
+}
+
+// GetEnvironmentSettings retrieves the settings of the environments from a YAML map,
+// keyed by environment name, in an environment variable. The settings are resolved but not
+// validated, so that every problem of the configuration is reported together.
+func GetEnvironmentSettings() (map[string]EnvironmentSettings, error) {
+	settings := map[string]EnvironmentSettings{}
+
+	value := os.Getenv(EnvironmentSettingsEnv)
+	if len(strings.TrimSpace(value)) == 0 {
+		return settings, nil
+	}
+
+	if err := yaml.Unmarshal([]byte(value), &settings); err != nil {
+		return nil, err
+	}
+
+	return ResolveEnvironmentSettings(settings)
+}
+
+// ValidateEnvironment checks that the settings of an environment are valid, and returns
+// every problem found.
+func ValidateEnvironment(env string, envSettings EnvironmentSettings, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+
+	for _, msg := range validation.IsDNS1123Label(EnvironmentDomain(env, envSettings)) {
+		errs = append(errs, field.Invalid(path.Child("domain"), EnvironmentDomain(env, envSettings), msg))
+	}
+	switch envSettings.SubdomainPolicy {
+	case "", SubdomainPolicyRewrite, SubdomainPolicyShard:
+	default:
+		errs = append(errs, field.NotSupported(path.Child("subdomainPolicy"), envSettings.SubdomainPolicy, []SubdomainPolicy{SubdomainPolicyRewrite, SubdomainPolicyShard}))
+	}
+	switch envSettings.HostCollision {
+	case "", HostCollisionWarn, HostCollisionDeny, HostCollisionDisambiguate:
+	default:
+		errs = append(errs, field.NotSupported(path.Child("hostCollision"), envSettings.HostCollision, []HostCollisionPolicy{HostCollisionWarn, HostCollisionDeny, HostCollisionDisambiguate}))
+	}
+	switch envSettings.CertificateCoverage {
+	case "", CertificateCoverageWarn, CertificateCoverageDeny:
+	default:
+		errs = append(errs, field.NotSupported(path.Child("certificateCoverage"), envSettings.CertificateCoverage, []CertificateCoveragePolicy{CertificateCoverageWarn, CertificateCoverageDeny}))
+	}
+	errs = append(errs, validateRouteTLS(envSettings.RouteTLS, path.Child("routeTLS"))...)
+	if envSettings.IngressClass != nil && len(envSettings.IngressClass.Name) == 0 {
+		errs = append(errs, field.Required(path.Child("ingressClass", "name"), "an ingress class must have a name"))
+	}
+	if envSettings.Router != nil && IngressControllerName(envSettings) == DefaultIngressController {
+		errs = append(errs, field.Required(path.Child("ingressController"), fmt.Sprintf("a router is provisioned, and the %q IngressController is not provisioned", DefaultIngressController)))
+	}
+	errs = append(errs, validateHostnameDimensions(envSettings.HostnameDimensions, path.Child("hostnameDimensions"))...)
+	if envSettings.HostnameDimensions != nil && envSettings.Router != nil {
+		errs = append(errs, field.Forbidden(path.Child("router"), "a provisioned router cannot serve the domains composed by hostname dimensions"))
+	}
+	errs = append(errs, validateNamespaceBundle(envSettings.Namespace, path.Child("namespace"))...)
+	for i, key := range envSettings.EnforcedRouteAnnotations {
+		if _, ok := envSettings.RouteAnnotations[key]; !ok {
+			errs = append(errs, field.Invalid(path.Child("enforcedRouteAnnotations").Index(i), key, "route annotation has no value"))
+		}
+	}
+
+	return errs
+}
+
+// validateRouteTLS validates the Route TLS policy of an environment.
+func validateRouteTLS(routeTLS *RouteTLS, path *field.Path) field.ErrorList {
+	if routeTLS == nil {
+		return nil
+	}
+
+	var errs field.ErrorList
+	switch routeTLS.Termination {
+	case "", routev1.TLSTerminationEdge, routev1.TLSTerminationPassthrough, routev1.TLSTerminationReencrypt:
+	default:
+		errs = append(errs, field.NotSupported(path.Child("termination"), routeTLS.Termination, []routev1.TLSTerminationType{routev1.TLSTerminationEdge, routev1.TLSTerminationPassthrough, routev1.TLSTerminationReencrypt}))
+	}
+
+	policyPath := path.Child("insecureEdgeTerminationPolicy")
+	switch routeTLS.InsecureEdgeTerminationPolicy {
+	case "", routev1.InsecureEdgeTerminationPolicyNone, routev1.InsecureEdgeTerminationPolicyRedirect:
+	case routev1.InsecureEdgeTerminationPolicyAllow:
+		if routeTLS.Termination == routev1.TLSTerminationPassthrough {
+			errs = append(errs, field.Invalid(policyPath, routeTLS.InsecureEdgeTerminationPolicy, fmt.Sprintf("not supported with termination %q", routeTLS.Termination)))
+		}
+		if routeTLS.Required {
+			errs = append(errs, field.Invalid(policyPath, routeTLS.InsecureEdgeTerminationPolicy, "not allowed when TLS is required"))
+		}
+	default:
+		errs = append(errs, field.NotSupported(policyPath, routeTLS.InsecureEdgeTerminationPolicy, []routev1.InsecureEdgeTerminationPolicyType{routev1.InsecureEdgeTerminationPolicyNone, routev1.InsecureEdgeTerminationPolicyRedirect, routev1.InsecureEdgeTerminationPolicyAllow}))
+	}
+
+	return errs
+}
+
+// validateNamespaceBundle validates the Namespace bundle of an environment.
+func validateNamespaceBundle(bundle *NamespaceBundle, path *field.Path) field.ErrorList {
+	if bundle == nil {
+		return nil
+	}
+
+	var errs field.ErrorList
+	for i, key := range bundle.Enforced {
+		_, isLabel := bundle.Labels[key]
+		_, isAnnotation := bundle.Annotations[key]
+		if !isLabel && !isAnnotation {
+			errs = append(errs, field.Invalid(path.Child("enforced").Index(i), key, "neither a label nor an annotation of the bundle"))
+		}
+	}
+
+	return errs
+}
@@ -12,20 +12,26 @@ import (
 
 	configv1 "github.com/openshift/api/config/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/discovery"
 )
 
 const (
 	Env                = "environments"
 	Key                = "environment"
 	clusterIngressName = "cluster"
-	bypassLabel        = "haproxy.router.dana.io/bypass-env-mutation"
 )
 
 // GetEnvironments retrieves environment data from a comma-separated environment variable.
-// It returns a slice containing the environments.
+// It returns a slice containing the non-empty environments.
 func GetEnvironments() []string {
-	environments := os.Getenv(Env)
-	return strings.Split(environments, ",")
+	var environments []string
+	for _, env := range strings.Split(os.Getenv(Env), ",") {
+		if env = strings.TrimSpace(env); len(env) > 0 {
+			environments = append(environments, env)
+		}
+	}
+
+	return environments
 }
 
 // GetClusterIngressDomain returns the ingress domain of an OpenShift cluster
@@ -37,15 +43,6 @@ func GetClusterIngressDomain(ctx context.Context, k8sClient client.Client) (stri
 	return ingress.Spec.Domain, nil
 }
 
-// CheckBypass checks if the namespace has the bypass mutation label.
-func CheckBypass(labels map[string]string) bool {
-	if val, ok := labels[bypassLabel]; ok && val == "true" {
-		return true
-	}
-
-	return false
-}
-
 // AppendLabels appends the received labels to the namespace.
 func AppendLabels(nsLabels, labels map[string]string) map[string]string {
 	if len(nsLabels) == 0 {
@@ -59,6 +56,21 @@ func AppendLabels(nsLabels, labels map[string]string) map[string]string {
 	return nsLabels
 }
 
+// AppendDefaults appends the received defaults to the map, without overriding existing keys.
+func AppendDefaults(values, defaults map[string]string) map[string]string {
+	if len(values) == 0 {
+		values = map[string]string{}
+	}
+
+	for key, value := range defaults {
+		if _, ok := values[key]; !ok {
+			values[key] = value
+		}
+	}
+
+	return values
+}
+
 // ModifyHostname modifies the hostname of the route/hostname based on the provided env.
 func ModifyHostname(logger logr.Logger, objectName, objectNamespace, hostName, env, clusterIngress string) string {
 	modifiedHostname := hostName
@@ -77,3 +89,41 @@ func ModifyHostname(logger logr.Logger, objectName, objectNamespace, hostName, e
 	}
 	return modifiedHostname
 }
+
+// ModifyHostnames modifies every non-empty hostname of a list based on the provided env.
+func ModifyHostnames(logger logr.Logger, objectName, objectNamespace string, hostNames []string, env, clusterIngress string) []string {
+	modifiedHostnames := make([]string, 0, len(hostNames))
+	for _, hostName := range hostNames {
+		if len(hostName) == 0 {
+			modifiedHostnames = append(modifiedHostnames, hostName)
+			continue
+		}
+		modifiedHostnames = append(modifiedHostnames, ModifyHostname(logger, objectName, objectNamespace, hostName, env, clusterIngress))
+	}
+	return modifiedHostnames
+}
+
+// NamespaceEnvironment returns the environment of a namespace, based on its environment label.
+func NamespaceEnvironment(labels map[string]string, environments []string) (string, bool) {
+	for _, env := range environments {
+		if len(env) > 0 && labels[Key] == env {
+			return env, true
+		}
+	}
+	return "", false
+}
+
+// IsGroupAvailable checks if an API group is served by the cluster.
+func IsGroupAvailable(discoveryClient discovery.DiscoveryInterface, group string) (bool, error) {
+	groups, err := discoveryClient.ServerGroups()
+	if err != nil {
+		return false, err
+	}
+
+	for _, apiGroup := range groups.Groups {
+		if apiGroup.Name == group {
+			return true, nil
+		}
+	}
+	return false, nil
+}
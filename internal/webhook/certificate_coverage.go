@@ -0,0 +1,136 @@
+package webhook
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"strings"
+
+	"github.com/dana-team/env-route-ns-mutator/internal/utils"
+	"github.com/go-logr/logr"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	routev1 "github.com/openshift/api/route/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// routerCertsPrefix prefixes the name of the Secret which the ingress operator generates
+// for an IngressController without a defaultCertificate.
+const routerCertsPrefix = "router-certs-"
+
+// +kubebuilder:rbac:groups="",namespace=openshift-ingress,resources=secrets,verbs=get;list;watch
+// +kubebuilder:rbac:groups="operator.openshift.io",resources=ingresscontrollers,verbs=get;list;watch
+
+// certificateCoverageError is returned when a host is not covered by the router certificate
+// and the certificate coverage policy of the environment denies it.
+type certificateCoverageError struct {
+	host              string
+	ingressController string
+}
+
+func (e *certificateCoverageError) Error() string {
+	return fmt.Sprintf("host %q is not covered by the default certificate of IngressController %q", e.host, e.ingressController)
+}
+
+// routerCertificateDNSNames returns the DNS names of the default certificate of an
+// IngressController.
+func routerCertificateDNSNames(ctx context.Context, k8sClient client.Client, name string) ([]string, error) {
+	ingressController := operatorv1.IngressController{}
+	if err := k8sClient.Get(ctx, types.NamespacedName{Name: name, Namespace: utils.IngressOperatorNamespace}, &ingressController); err != nil {
+		return nil, err
+	}
+
+	secretName := routerCertsPrefix + name
+	if ingressController.Spec.DefaultCertificate != nil {
+		secretName = ingressController.Spec.DefaultCertificate.Name
+	}
+
+	secret := corev1.Secret{}
+	if err := k8sClient.Get(ctx, types.NamespacedName{Name: secretName, Namespace: utils.IngressNamespace}, &secret); err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(secret.Data[corev1.TLSCertKey])
+	if block == nil {
+		return nil, fmt.Errorf("secret %s/%s has no PEM certificate", utils.IngressNamespace, secretName)
+	}
+
+	certificate, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	return certificate.DNSNames, nil
+}
+
+// coveredBy checks if a host matches one of the DNS names of a certificate. A wildcard
+// DNS name covers exactly one label.
+func coveredBy(host string, dnsNames []string) bool {
+	for _, dnsName := range dnsNames {
+		if strings.EqualFold(dnsName, host) {
+			return true
+		}
+		if wildcardDomain, ok := strings.CutPrefix(dnsName, "*."); ok {
+			if _, domain, ok := strings.Cut(host, "."); ok && strings.EqualFold(domain, wildcardDomain) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// checkCoverage applies the certificate coverage policy of the environment to a host in
+// the environment domain. It returns a warning when the host is not covered by the default
+// certificate of the IngressController of the environment, or a certificateCoverageError
+// when the policy denies the host. When the certificate cannot be read, the host is only
+// denied by the Deny policy, and gets a warning otherwise.
+func checkCoverage(ctx context.Context, logger logr.Logger, k8sClient client.Client, host, envDomain string, settings utils.EnvironmentSettings) (string, error) {
+	if len(settings.CertificateCoverage) == 0 || !strings.HasSuffix(host, "."+envDomain) {
+		return "", nil
+	}
+
+	ingressController := utils.IngressControllerName(settings)
+	dnsNames, err := routerCertificateDNSNames(ctx, k8sClient, ingressController)
+	if err != nil {
+		if settings.CertificateCoverage == utils.CertificateCoverageDeny {
+			return "", err
+		}
+		logger.Error(err, "failed to get the router certificate", "ingressController", ingressController)
+		return fmt.Sprintf("failed to verify that host %q is covered by the default certificate of IngressController %q: %v", host, ingressController, err), nil
+	}
+	if coveredBy(host, dnsNames) {
+		return "", nil
+	}
+
+	if settings.CertificateCoverage == utils.CertificateCoverageDeny {
+		logger.Info("Denying host which is not covered by the router certificate", "hostname", host, "ingressController", ingressController)
+		return "", &certificateCoverageError{host: host, ingressController: ingressController}
+	}
+
+	logger.Info("Host is not covered by the router certificate", "hostname", host, "ingressController", ingressController)
+	return fmt.Sprintf("host %q is not covered by the default certificate of IngressController %q, clients will get TLS errors", host, ingressController), nil
+}
+
+// usesRouterCertificate checks if a Route is served with the default certificate of the router.
+func usesRouterCertificate(route *routev1.Route) bool {
+	tls := route.Spec.TLS
+	return tls != nil && tls.Termination != routev1.TLSTerminationPassthrough && len(tls.Certificate) == 0 && tls.ExternalCertificate == nil
+}
+
+// routerCertificateHosts returns the hosts of an Ingress which are served with the default
+// certificate of the router, i.e. listed in a TLS entry without a secretName.
+func routerCertificateHosts(ingress *networkingv1.Ingress) []string {
+	var hosts []string
+	for _, tls := range ingress.Spec.TLS {
+		if len(tls.SecretName) > 0 {
+			continue
+		}
+		hosts = append(hosts, tls.Hosts...)
+	}
+
+	return hosts
+}
@@ -0,0 +1,180 @@
+package webhook
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/dana-team/env-route-ns-mutator/internal/utils"
+
+	. "github.com/onsi/gomega"
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	routev1 "github.com/openshift/api/route/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	testclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// testCertificate returns a PEM self-signed certificate for the DNS names.
+func testCertificate(t *testing.T, dnsNames ...string) []byte {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: dnsNames[0]},
+		DNSNames:     dnsNames,
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func TestCheckCoverage(t *testing.T) {
+	logger := ctrl.Log.WithName("webhook")
+
+	const customCertificate = "env2-certificate"
+
+	env1Domain := fmt.Sprintf("%s-%s", env1, clusterIngressDomain)
+	env2Domain := fmt.Sprintf("%s-%s", env2, clusterIngressDomain)
+
+	testScheme := runtime.NewScheme()
+	utilruntime.Must(scheme.AddToScheme(testScheme))
+	utilruntime.Must(operatorv1.Install(testScheme))
+
+	objects := []runtime.Object{
+		&operatorv1.IngressController{ObjectMeta: metav1.ObjectMeta{Name: utils.DefaultIngressController, Namespace: utils.IngressOperatorNamespace}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: routerCertsPrefix + utils.DefaultIngressController, Namespace: utils.IngressNamespace}, Data: map[string][]byte{corev1.TLSCertKey: testCertificate(t, "*."+env1Domain)}},
+		&operatorv1.IngressController{ObjectMeta: metav1.ObjectMeta{Name: env2, Namespace: utils.IngressOperatorNamespace}, Spec: operatorv1.IngressControllerSpec{DefaultCertificate: &corev1.LocalObjectReference{Name: customCertificate}}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: customCertificate, Namespace: utils.IngressNamespace}, Data: map[string][]byte{corev1.TLSCertKey: testCertificate(t, "*."+env2Domain, "a.b."+env2Domain)}},
+	}
+	client := testclient.NewClientBuilder().WithScheme(testScheme).WithRuntimeObjects(objects...).Build()
+
+	warn := utils.EnvironmentSettings{CertificateCoverage: utils.CertificateCoverageWarn}
+	deny := utils.EnvironmentSettings{CertificateCoverage: utils.CertificateCoverageDeny}
+	env2Deny := utils.EnvironmentSettings{CertificateCoverage: utils.CertificateCoverageDeny, IngressController: env2}
+	missingWarn := utils.EnvironmentSettings{CertificateCoverage: utils.CertificateCoverageWarn, IngressController: "missing"}
+	missingDeny := utils.EnvironmentSettings{CertificateCoverage: utils.CertificateCoverageDeny, IngressController: "missing"}
+
+	tests := []struct {
+		name      string
+		host      string
+		envDomain string
+		settings  utils.EnvironmentSettings
+		warned    bool
+		denied    bool
+		failed    bool
+	}{
+		{name: "hostCoveredByWildcard", host: "a." + env1Domain, envDomain: env1Domain, settings: deny},
+		{name: "nestedHostWarn", host: "a.b." + env1Domain, envDomain: env1Domain, settings: warn, warned: true},
+		{name: "nestedHostDeny", host: "a.b." + env1Domain, envDomain: env1Domain, settings: deny, denied: true},
+		{name: "nestedHostCheckDisabled", host: "a.b." + env1Domain, envDomain: env1Domain, settings: utils.EnvironmentSettings{}},
+		{name: "hostOutsideEnvironmentDomain", host: "a.b.custom.com", envDomain: env1Domain, settings: deny},
+		{name: "nestedHostCoveredByDefaultCertificate", host: "a.b." + env2Domain, envDomain: env2Domain, settings: env2Deny},
+		{name: "nestedHostNotCoveredByDefaultCertificate", host: "c.d." + env2Domain, envDomain: env2Domain, settings: env2Deny, denied: true},
+		{name: "missingIngressControllerWarn", host: "a." + env1Domain, envDomain: env1Domain, settings: missingWarn, warned: true},
+		{name: "missingIngressControllerDeny", host: "a." + env1Domain, envDomain: env1Domain, settings: missingDeny, failed: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			warning, err := checkCoverage(context.Background(), logger, client, tc.host, tc.envDomain, tc.settings)
+			if tc.denied {
+				var coverageErr *certificateCoverageError
+				g.Expect(errors.As(err, &coverageErr)).To(BeTrue())
+				return
+			}
+			if tc.failed {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(len(warning) > 0).To(Equal(tc.warned))
+		})
+	}
+}
+
+func TestIngressMutatorCertificateCoverage(t *testing.T) {
+	env1Domain := fmt.Sprintf("%s-%s", env1, clusterIngressDomain)
+	coveredHost := "a." + clusterIngressDomain
+	uncoveredHost := "a.b." + clusterIngressDomain
+
+	t.Setenv(utils.Env, env1)
+	t.Setenv(utils.EnvironmentSettingsEnv, fmt.Sprintf("{%s: {certificateCoverage: Deny}}", env1))
+
+	testScheme := runtime.NewScheme()
+	utilruntime.Must(scheme.AddToScheme(testScheme))
+	utilruntime.Must(operatorv1.Install(testScheme))
+	utilruntime.Must(routev1.AddToScheme(testScheme))
+	utilruntime.Must(configv1.Install(testScheme))
+
+	objects := []runtime.Object{
+		&configv1.Ingress{ObjectMeta: metav1.ObjectMeta{Name: "cluster"}, Spec: configv1.IngressSpec{Domain: clusterIngressDomain}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: testNamespace, Labels: map[string]string{utils.Key: env1}}},
+		&operatorv1.IngressController{ObjectMeta: metav1.ObjectMeta{Name: utils.DefaultIngressController, Namespace: utils.IngressOperatorNamespace}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: routerCertsPrefix + utils.DefaultIngressController, Namespace: utils.IngressNamespace}, Data: map[string][]byte{corev1.TLSCertKey: testCertificate(t, "*."+env1Domain)}},
+	}
+	k8sClient := testclient.NewClientBuilder().WithScheme(testScheme).WithRuntimeObjects(objects...).
+		WithIndex(&routev1.Route{}, routeHostIndex, routeHostIndexer).
+		WithIndex(&networkingv1.Ingress{}, ingressHostIndex, ingressHostIndexer).
+		Build()
+
+	tests := []struct {
+		name    string
+		host    string
+		tls     []networkingv1.IngressTLS
+		allowed bool
+	}{
+		{name: "plainHTTP", host: uncoveredHost, allowed: true},
+		{name: "tlsWithSecret", host: uncoveredHost, tls: []networkingv1.IngressTLS{{Hosts: []string{uncoveredHost}, SecretName: "certificate"}}, allowed: true},
+		{name: "tlsWithRouterCertificate", host: uncoveredHost, tls: []networkingv1.IngressTLS{{Hosts: []string{uncoveredHost}}}, allowed: false},
+		{name: "coveredTLSWithRouterCertificate", host: coveredHost, tls: []networkingv1.IngressTLS{{Hosts: []string{coveredHost}}}, allowed: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			rm := IngressMutator{Decoder: admission.NewDecoder(testScheme), Client: k8sClient}
+			ingress := &networkingv1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{Name: tc.name, Namespace: testNamespace},
+				Spec:       networkingv1.IngressSpec{Rules: []networkingv1.IngressRule{{Host: tc.host}}, TLS: tc.tls},
+			}
+
+			response := rm.Handle(context.Background(), admissionRequest(g, ingress, nil, authenticationv1.UserInfo{Username: "developer"}))
+
+			g.Expect(response.Allowed).To(Equal(tc.allowed))
+			if tc.allowed {
+				g.Expect(response.Warnings).To(BeEmpty())
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package webhook
+
+import (
+	"context"
+
+	"github.com/dana-team/env-route-ns-mutator/internal/utils"
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const CertManagerGroup = "cert-manager.io"
+
+// CertificateMutator is the struct used to mutate cert-manager Certificates.
+// The objects are handled as unstructured objects, so cert-manager is optional.
+type CertificateMutator struct {
+	Decoder admission.Decoder
+	Client  client.Client
+}
+
+// +kubebuilder:webhook:path=/mutate-v1-certificate,mutating=true,failurePolicy=ignore,sideEffects=None,groups=cert-manager.io,resources=certificates,verbs=create,versions=v1,name=certificate.dana.io,admissionReviewVersions=v1;v1beta1
+
+func (r *CertificateMutator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	logger := log.FromContext(ctx).WithName("Certificate").WithValues("name", req.Name)
+	logger.Info("webhook request received")
+
+	return handleUnstructured(ctx, logger, req, r.Decoder, r.Client, r)
+}
+
+// handleInner implements the main mutating logic. It modifies the dnsNames and commonName
+// of a Certificate the same way the hosts of Routes and Ingresses are modified, so that the
+// Certificate matches them, and sets the issuer of the environment when one is configured.
+func (r *CertificateMutator) handleInner(_ context.Context, logger logr.Logger, certificate *unstructured.Unstructured, clusterIngress string, environments []string, settings map[string]utils.EnvironmentSettings, labels map[string]string) error {
+	env, ok := utils.NamespaceEnvironment(labels, environments)
+	if !ok {
+		return nil
+	}
+	domain, err := utils.EnvironmentHostDomain(env, settings[env], labels)
+	if err != nil {
+		return err
+	}
+
+	dnsNames, found, err := unstructured.NestedStringSlice(certificate.Object, "spec", "dnsNames")
+	if err != nil {
+		return err
+	}
+	if found {
+		dnsNames = utils.ModifyHostnames(logger, certificate.GetName(), certificate.GetNamespace(), dnsNames, domain, clusterIngress)
+		if err := unstructured.SetNestedStringSlice(certificate.Object, dnsNames, "spec", "dnsNames"); err != nil {
+			return err
+		}
+	}
+
+	commonName, _, err := unstructured.NestedString(certificate.Object, "spec", "commonName")
+	if err != nil {
+		return err
+	}
+	if len(commonName) > 0 {
+		commonName = utils.ModifyHostname(logger, certificate.GetName(), certificate.GetNamespace(), commonName, domain, clusterIngress)
+		if err := unstructured.SetNestedField(certificate.Object, commonName, "spec", "commonName"); err != nil {
+			return err
+		}
+	}
+
+	if issuerRef := settings[env].IssuerRef; issuerRef != nil {
+		issuer := map[string]interface{}{"name": issuerRef.Name}
+		if len(issuerRef.Kind) > 0 {
+			issuer["kind"] = issuerRef.Kind
+		}
+		if len(issuerRef.Group) > 0 {
+			issuer["group"] = issuerRef.Group
+		}
+		if err := unstructured.SetNestedMap(certificate.Object, issuer, "spec", "issuerRef"); err != nil {
+			return err
+		}
+		logger.Info("IssuerRef set to the environment issuer", "issuer", issuerRef.Name)
+	}
+
+	return nil
+}
@@ -0,0 +1,87 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/dana-team/env-route-ns-mutator/internal/utils"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	testclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const (
+	userIssuer = "user-issuer"
+	envIssuer  = "env1-issuer"
+)
+
+func TestCertificateMutator(t *testing.T) {
+	logger := ctrl.Log.WithName("webhook")
+
+	environments := []string{env1, env2}
+	settings := map[string]utils.EnvironmentSettings{
+		env1: {IssuerRef: &utils.IssuerRef{Name: envIssuer, Kind: "ClusterIssuer", Group: "cert-manager.io"}},
+	}
+
+	tests := []struct {
+		name               string
+		dnsNames           []string
+		commonName         string
+		nsLabels           map[string]string
+		expectedDNSNames   []string
+		expectedCommonName string
+		expectedIssuer     string
+	}{
+		{name: "certificateWithDefaultDomain", dnsNames: []string{fmt.Sprintf("test1.%s", clusterIngressDomain), "test1.custom.com"}, commonName: fmt.Sprintf("test1.%s", clusterIngressDomain), nsLabels: map[string]string{utils.Key: env1}, expectedDNSNames: []string{fmt.Sprintf("test1.%s-%s", env1, clusterIngressDomain), "test1.custom.com"}, expectedCommonName: fmt.Sprintf("test1.%s-%s", env1, clusterIngressDomain), expectedIssuer: envIssuer},
+		{name: "certificateWithWildcard", dnsNames: []string{fmt.Sprintf("*.%s", clusterIngressDomain)}, nsLabels: map[string]string{utils.Key: env1}, expectedDNSNames: []string{fmt.Sprintf("*.%s-%s", env1, clusterIngressDomain)}, expectedIssuer: envIssuer},
+		{name: "certificateWithoutIssuerSetting", dnsNames: []string{fmt.Sprintf("test3.%s", clusterIngressDomain)}, nsLabels: map[string]string{utils.Key: env2}, expectedDNSNames: []string{fmt.Sprintf("test3.%s-%s", env2, clusterIngressDomain)}, expectedIssuer: userIssuer},
+		{name: "certificateWithoutLabels", dnsNames: []string{fmt.Sprintf("test4.%s", clusterIngressDomain)}, nsLabels: map[string]string{}, expectedDNSNames: []string{fmt.Sprintf("test4.%s", clusterIngressDomain)}, expectedIssuer: userIssuer},
+	}
+
+	client := testclient.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			rm := CertificateMutator{Decoder: admission.NewDecoder(scheme.Scheme), Client: client}
+
+			dnsNames := make([]interface{}, 0, len(tc.dnsNames))
+			for _, dnsName := range tc.dnsNames {
+				dnsNames = append(dnsNames, dnsName)
+			}
+			spec := map[string]interface{}{
+				"dnsNames":   dnsNames,
+				"secretName": tc.name,
+				"issuerRef":  map[string]interface{}{"name": userIssuer},
+			}
+			if len(tc.commonName) > 0 {
+				spec["commonName"] = tc.commonName
+			}
+			certificate := &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "cert-manager.io/v1",
+				"kind":       "Certificate",
+				"metadata":   map[string]interface{}{"name": tc.name, "namespace": testNamespace},
+				"spec":       spec,
+			}}
+
+			g.Expect(rm.handleInner(context.Background(), logger, certificate, clusterIngressDomain, environments, settings, tc.nsLabels)).To(Succeed())
+
+			mutatedDNSNames, _, err := unstructured.NestedStringSlice(certificate.Object, "spec", "dnsNames")
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(mutatedDNSNames).To(Equal(tc.expectedDNSNames))
+
+			commonName, _, err := unstructured.NestedString(certificate.Object, "spec", "commonName")
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(commonName).To(Equal(tc.expectedCommonName))
+
+			issuer, _, err := unstructured.NestedString(certificate.Object, "spec", "issuerRef", "name")
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(issuer).To(Equal(tc.expectedIssuer))
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package webhook
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/dana-team/env-route-ns-mutator/internal/utils"
+	"github.com/go-logr/logr"
+	routev1 "github.com/openshift/api/route/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const (
+	routeHostIndex   = "spec.host"
+	ingressHostIndex = "spec.rules.host"
+
+	// disambiguationHashLength is the number of hex characters of the namespace hash
+	// which suffix the first label of a disambiguated host.
+	disambiguationHashLength = 5
+	maxDNSLabelLength        = 63
+)
+
+// hostCollisionError is returned when a host is held in another namespace and the host
+// collision policy of the environment denies it.
+type hostCollisionError struct {
+	host   string
+	holder string
+}
+
+func (e *hostCollisionError) Error() string {
+	return fmt.Sprintf("host %q is already held by %s", e.host, e.holder)
+}
+
+// SetupIndexes registers the field indexes used to find the Routes and Ingresses holding a host.
+func SetupIndexes(ctx context.Context, indexer client.FieldIndexer) error {
+	if err := indexer.IndexField(ctx, &routev1.Route{}, routeHostIndex, routeHostIndexer); err != nil {
+		return err
+	}
+
+	return indexer.IndexField(ctx, &networkingv1.Ingress{}, ingressHostIndex, ingressHostIndexer)
+}
+
+// routeHostIndexer indexes a Route by its host.
+func routeHostIndexer(obj client.Object) []string {
+	route, ok := obj.(*routev1.Route)
+	if !ok || len(route.Spec.Host) == 0 {
+		return nil
+	}
+
+	return []string{route.Spec.Host}
+}
+
+// ingressHostIndexer indexes an Ingress by the hosts of its rules.
+func ingressHostIndexer(obj client.Object) []string {
+	ingress, ok := obj.(*networkingv1.Ingress)
+	if !ok {
+		return nil
+	}
+
+	var hosts []string
+	for _, rule := range ingress.Spec.Rules {
+		if len(rule.Host) > 0 {
+			hosts = append(hosts, rule.Host)
+		}
+	}
+
+	return hosts
+}
+
+// hostHolder returns the Route or Ingress in another namespace which holds the host.
+func hostHolder(ctx context.Context, k8sClient client.Client, host, namespace string) (string, bool, error) {
+	routes := routev1.RouteList{}
+	if err := k8sClient.List(ctx, &routes, client.MatchingFields{routeHostIndex: host}); err != nil {
+		return "", false, err
+	}
+	for _, route := range routes.Items {
+		if route.Namespace != namespace {
+			return fmt.Sprintf("Route %s/%s", route.Namespace, route.Name), true, nil
+		}
+	}
+
+	ingresses := networkingv1.IngressList{}
+	if err := k8sClient.List(ctx, &ingresses, client.MatchingFields{ingressHostIndex: host}); err != nil {
+		return "", false, err
+	}
+	for _, ingress := range ingresses.Items {
+		if ingress.Namespace != namespace {
+			return fmt.Sprintf("Ingress %s/%s", ingress.Namespace, ingress.Name), true, nil
+		}
+	}
+
+	return "", false, nil
+}
+
+// resolveHost applies the host collision policy of the environment to a host in the
+// environment domain which is already held in another namespace. It returns the host to
+// use and a warning, or a hostCollisionError when the policy denies the host.
+func resolveHost(ctx context.Context, logger logr.Logger, k8sClient client.Client, host, namespace, envDomain string, policy utils.HostCollisionPolicy) (string, string, error) {
+	if !strings.HasSuffix(host, "."+envDomain) || strings.HasPrefix(host, "*.") {
+		return host, "", nil
+	}
+
+	holder, held, err := hostHolder(ctx, k8sClient, host, namespace)
+	if err != nil || !held {
+		return host, "", err
+	}
+
+	switch policy {
+	case utils.HostCollisionDeny:
+		logger.Info("Denying host held in another namespace", "hostname", host, "holder", holder)
+		return "", "", &hostCollisionError{host: host, holder: holder}
+	case utils.HostCollisionDisambiguate:
+		disambiguated := disambiguateHost(host, namespace)
+		logger.Info("Disambiguating host held in another namespace", "hostname", host, "holder", holder, "disambiguated", disambiguated)
+		if _, held, err := hostHolder(ctx, k8sClient, disambiguated, namespace); err != nil || held {
+			return host, fmt.Sprintf("host %q is already held by %s, and so is the disambiguated host %q", host, holder, disambiguated), err
+		}
+		return disambiguated, fmt.Sprintf("host %q is already held by %s, %q is used instead", host, holder, disambiguated), nil
+	default:
+		logger.Info("Host is held in another namespace", "hostname", host, "holder", holder)
+		return host, fmt.Sprintf("host %q is already held by %s, the router admits only the oldest", host, holder), nil
+	}
+}
+
+// disambiguateHost suffixes the first label of a host with a hash of the namespace.
+func disambiguateHost(host, namespace string) string {
+	sum := sha256.Sum256([]byte(namespace))
+	suffix := "-" + hex.EncodeToString(sum[:])[:disambiguationHashLength]
+
+	label, rest, _ := strings.Cut(host, ".")
+	if len(label)+len(suffix) > maxDNSLabelLength {
+		label = strings.TrimRight(label[:maxDNSLabelLength-len(suffix)], "-")
+	}
+
+	return label + suffix + "." + rest
+}
+
+// hostErrorResponse returns a denied response when a host policy of the environment denies
+// a host, and an errored response otherwise.
+func hostErrorResponse(logger logr.Logger, err error) admission.Response {
+	var collisionErr *hostCollisionError
+	var coverageErr *certificateCoverageError
+	if errors.As(err, &collisionErr) || errors.As(err, &coverageErr) {
+		return admission.Denied(err.Error())
+	}
+
+	logger.Error(err, "failed to verify host")
+	return admission.Errored(http.StatusInternalServerError, err)
+}
+
+// appendWarning appends a warning, if there is one.
+func appendWarning(warnings []string, warning string) []string {
+	if len(warning) == 0 {
+		return warnings
+	}
+
+	return append(warnings, warning)
+}
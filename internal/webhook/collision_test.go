@@ -0,0 +1,149 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/dana-team/env-route-ns-mutator/internal/utils"
+
+	. "github.com/onsi/gomega"
+	configv1 "github.com/openshift/api/config/v1"
+	routev1 "github.com/openshift/api/route/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	testclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func TestResolveHost(t *testing.T) {
+	logger := ctrl.Log.WithName("webhook")
+
+	envDomain := fmt.Sprintf("%s-%s", env1, clusterIngressDomain)
+	routeHost := fmt.Sprintf("a-b-c.%s", envDomain)
+	ingressHost := fmt.Sprintf("ingress.%s", envDomain)
+	customHost := "a-b-c.custom.com"
+
+	testScheme := runtime.NewScheme()
+	utilruntime.Must(scheme.AddToScheme(testScheme))
+	utilruntime.Must(routev1.AddToScheme(testScheme))
+
+	objects := []runtime.Object{
+		&routev1.Route{ObjectMeta: metav1.ObjectMeta{Name: "a-b", Namespace: "c"}, Spec: routev1.RouteSpec{Host: routeHost}},
+		&routev1.Route{ObjectMeta: metav1.ObjectMeta{Name: "custom", Namespace: "c"}, Spec: routev1.RouteSpec{Host: customHost}},
+		&networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{Name: "ingress", Namespace: "other"}, Spec: networkingv1.IngressSpec{Rules: []networkingv1.IngressRule{{Host: ingressHost}}}},
+	}
+	client := testclient.NewClientBuilder().WithScheme(testScheme).WithRuntimeObjects(objects...).
+		WithIndex(&routev1.Route{}, routeHostIndex, routeHostIndexer).
+		WithIndex(&networkingv1.Ingress{}, ingressHostIndex, ingressHostIndexer).
+		Build()
+
+	tests := []struct {
+		name         string
+		host         string
+		namespace    string
+		policy       utils.HostCollisionPolicy
+		expectedHost string
+		warned       bool
+		denied       bool
+	}{
+		{name: "hostNotHeld", host: fmt.Sprintf("free.%s", envDomain), namespace: "b-c", policy: utils.HostCollisionDeny, expectedHost: fmt.Sprintf("free.%s", envDomain)},
+		{name: "hostHeldInSameNamespace", host: routeHost, namespace: "c", policy: utils.HostCollisionDeny, expectedHost: routeHost},
+		{name: "hostHeldWarn", host: routeHost, namespace: "b-c", policy: "", expectedHost: routeHost, warned: true},
+		{name: "hostHeldDeny", host: routeHost, namespace: "b-c", policy: utils.HostCollisionDeny, denied: true},
+		{name: "hostHeldDisambiguate", host: routeHost, namespace: "b-c", policy: utils.HostCollisionDisambiguate, expectedHost: disambiguateHost(routeHost, "b-c"), warned: true},
+		{name: "hostHeldByIngress", host: ingressHost, namespace: "b-c", policy: utils.HostCollisionDeny, denied: true},
+		{name: "hostOutsideEnvironmentDomain", host: customHost, namespace: "b-c", policy: utils.HostCollisionDeny, expectedHost: customHost},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			host, warning, err := resolveHost(context.Background(), logger, client, tc.host, tc.namespace, envDomain, tc.policy)
+			if tc.denied {
+				var collisionErr *hostCollisionError
+				g.Expect(errors.As(err, &collisionErr)).To(BeTrue())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(host).To(Equal(tc.expectedHost))
+			g.Expect(len(warning) > 0).To(Equal(tc.warned))
+		})
+	}
+}
+
+func TestRouteMutatorHostCollision(t *testing.T) {
+	host := fmt.Sprintf("app-%s.%s-%s", testNamespace, env1, clusterIngressDomain)
+
+	testScheme := runtime.NewScheme()
+	utilruntime.Must(scheme.AddToScheme(testScheme))
+	utilruntime.Must(routev1.AddToScheme(testScheme))
+	utilruntime.Must(configv1.Install(testScheme))
+
+	objects := []runtime.Object{
+		&configv1.Ingress{ObjectMeta: metav1.ObjectMeta{Name: "cluster"}, Spec: configv1.IngressSpec{Domain: clusterIngressDomain}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: testNamespace, Labels: map[string]string{utils.Key: env1}}},
+		&routev1.Route{ObjectMeta: metav1.ObjectMeta{Name: "holder", Namespace: "other"}, Spec: routev1.RouteSpec{Host: host}},
+	}
+	k8sClient := testclient.NewClientBuilder().WithScheme(testScheme).WithRuntimeObjects(objects...).
+		WithIndex(&routev1.Route{}, routeHostIndex, routeHostIndexer).
+		WithIndex(&networkingv1.Ingress{}, ingressHostIndex, ingressHostIndexer).
+		Build()
+
+	tests := []struct {
+		name         string
+		policy       utils.HostCollisionPolicy
+		expectedHost string
+		allowed      bool
+	}{
+		{name: "warn", policy: "", expectedHost: host, allowed: true},
+		{name: "deny", policy: utils.HostCollisionDeny, allowed: false},
+		{name: "disambiguate", policy: utils.HostCollisionDisambiguate, expectedHost: disambiguateHost(host, testNamespace), allowed: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			t.Setenv(utils.Env, env1)
+			t.Setenv(utils.EnvironmentSettingsEnv, fmt.Sprintf("{%s: {hostCollision: %q}}", env1, tc.policy))
+
+			rm := RouteMutator{Decoder: admission.NewDecoder(testScheme), Client: k8sClient}
+			route := &routev1.Route{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: testNamespace}}
+
+			response := rm.Handle(context.Background(), admissionRequest(g, route, nil, authenticationv1.UserInfo{Username: "developer"}))
+
+			g.Expect(response.Allowed).To(Equal(tc.allowed))
+			if !tc.allowed {
+				g.Expect(response.Result.Message).To(ContainSubstring("Route other/holder"))
+				return
+			}
+			g.Expect(response.Warnings).To(ContainElement(ContainSubstring("Route other/holder")))
+			g.Expect(response.Patches).To(ContainElement(SatisfyAll(
+				HaveField("Path", "/spec/host"),
+				HaveField("Value", tc.expectedHost),
+			)))
+		})
+	}
+}
+
+func TestDisambiguateHost(t *testing.T) {
+	g := NewWithT(t)
+
+	host := fmt.Sprintf("test.%s-%s", env1, clusterIngressDomain)
+	disambiguated := disambiguateHost(host, testNamespace)
+	g.Expect(disambiguated).To(MatchRegexp(`^test-[0-9a-f]{5}\.%s-%s$`, env1, clusterIngressDomain))
+	g.Expect(disambiguateHost(host, "other-ns")).NotTo(Equal(disambiguated))
+
+	longLabel := fmt.Sprintf("%063d", 0)
+	label, _, _ := strings.Cut(disambiguateHost(longLabel+".apps.example.com", testNamespace), ".")
+	g.Expect(len(label)).To(Equal(maxDNSLabelLength))
+}
@@ -0,0 +1,106 @@
+package webhook
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/dana-team/env-route-ns-mutator/internal/config"
+	"github.com/dana-team/env-route-ns-mutator/internal/utils"
+
+	. "github.com/onsi/gomega"
+	routev1 "github.com/openshift/api/route/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	testclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const regionLabel = "topology.dana.io/region"
+
+func TestHostnameDimensions(t *testing.T) {
+	logger := ctrl.Log.WithName("webhook")
+
+	environments := []string{env1, env2}
+	envRegion := &utils.HostnameDimensions{Labels: []string{utils.Key, regionLabel}}
+	regionEnv := &utils.HostnameDimensions{Labels: []string{regionLabel, utils.Key}, Separator: "."}
+
+	tests := []struct {
+		name       string
+		dimensions *utils.HostnameDimensions
+		domain     string
+		region     string
+		expected   string
+		invalid    bool
+	}{
+		{name: "noDimensions", expected: env1},
+		{name: "environmentAndRegion", dimensions: envRegion, region: "eu", expected: "env1-eu"},
+		{name: "environmentDomainAndRegion", dimensions: envRegion, domain: "prod", region: "eu", expected: "prod-eu"},
+		{name: "regionFirstWithSeparator", dimensions: regionEnv, region: "eu", expected: "eu.env1"},
+		{name: "missingRegion", dimensions: envRegion, invalid: true},
+		{name: "invalidRegion", dimensions: envRegion, region: "EU", invalid: true},
+	}
+
+	client := testclient.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			rm := RouteMutator{Decoder: admission.NewDecoder(scheme.Scheme), Client: client}
+			route := &routev1.Route{ObjectMeta: metav1.ObjectMeta{Name: tc.name, Namespace: testNamespace}}
+			settings := map[string]utils.EnvironmentSettings{env1: {Domain: tc.domain, HostnameDimensions: tc.dimensions}}
+			labels := map[string]string{utils.Key: env1}
+			if len(tc.region) > 0 {
+				labels[regionLabel] = tc.region
+			}
+
+			err := rm.handleInner(logger, route, clusterIngressDomain, environments, settings, labels)
+			if tc.invalid {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(route.Spec.Host).To(BeEmpty())
+				return
+			}
+
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(route.Spec.Host).To(Equal(fmt.Sprintf("%s-%s.%s-%s", tc.name, testNamespace, tc.expected, clusterIngressDomain)))
+		})
+	}
+}
+
+func TestHostnameDimensionsValidation(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings string
+		valid    bool
+	}{
+		{name: "valid", settings: "{env1: {hostnameDimensions: {labels: [environment, topology.dana.io/region], separator: '-'}}}", valid: true},
+		{name: "inherited", settings: "{env1: {hostnameDimensions: {labels: [environment, topology.dana.io/region]}}, env2: {parent: env1}}", valid: true},
+		{name: "noLabels", settings: "{env1: {hostnameDimensions: {labels: []}}}", valid: false},
+		{name: "duplicateLabel", settings: "{env1: {hostnameDimensions: {labels: [environment, environment]}}}", valid: false},
+		{name: "invalidLabel", settings: "{env1: {hostnameDimensions: {labels: ['topology/dana/region']}}}", valid: false},
+		{name: "invalidSeparator", settings: "{env1: {hostnameDimensions: {labels: [environment], separator: '_'}}}", valid: false},
+		{name: "withoutEnvironment", settings: "{env1: {hostnameDimensions: {labels: [topology.dana.io/region]}}}", valid: false},
+		{name: "withRouter", settings: "{env1: {ingressController: env1, router: {}, hostnameDimensions: {labels: [environment, topology.dana.io/region]}}}", valid: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			t.Setenv(utils.EnvironmentSettingsEnv, tc.settings)
+			settings, err := utils.GetEnvironmentSettings()
+			g.Expect(err).NotTo(HaveOccurred())
+
+			err = config.Validate(&config.Config{Environments: []string{env1, env2}, EnvironmentSettings: settings})
+			if !tc.valid {
+				g.Expect(err).To(MatchError(ContainSubstring("environmentSettings[env1]")))
+				return
+			}
+
+			g.Expect(err).NotTo(HaveOccurred())
+			for _, envSettings := range settings {
+				g.Expect(envSettings.HostnameDimensions).NotTo(BeNil())
+			}
+		})
+	}
+}
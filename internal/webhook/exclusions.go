@@ -0,0 +1,55 @@
+package webhook
+
+import (
+	"github.com/dana-team/env-route-ns-mutator/internal/utils"
+	"github.com/go-logr/logr"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const (
+	excludedReason = "excluded from environment mutation"
+	bypassedReason = "bypassed environment mutation"
+)
+
+// excludedByName returns an allowed response if the namespace name is excluded from mutation.
+func excludedByName(logger logr.Logger, exclusions utils.Exclusions, namespace string) (admission.Response, bool) {
+	if pattern, ok := exclusions.ExcludesNamespaceName(namespace); ok {
+		logger.Info("Namespace is excluded from mutation", "pattern", pattern)
+		return admission.Allowed(excludedReason), true
+	}
+
+	return admission.Response{}, false
+}
+
+// excludedByLabels returns an allowed response if the labels of the namespace
+// or of the object itself are excluded from mutation.
+func excludedByLabels(logger logr.Logger, exclusions utils.Exclusions, nsLabels, objectLabels map[string]string) (admission.Response, bool) {
+	if requirement, ok := exclusions.ExcludesNamespaceLabels(nsLabels); ok {
+		logger.Info("Namespace labels are excluded from mutation", "requirement", requirement)
+		return admission.Allowed(excludedReason), true
+	}
+
+	if requirement, ok := exclusions.ExcludesObjectLabels(objectLabels); ok {
+		logger.Info("Object labels are excluded from mutation", "requirement", requirement)
+		return admission.Allowed(excludedReason), true
+	}
+
+	return admission.Response{}, false
+}
+
+// bypassed returns an allowed response if the namespace has the bypass label or the
+// requester is a bypass user.
+func bypassed(logger logr.Logger, bypass utils.Bypass, nsLabels map[string]string, userInfo authenticationv1.UserInfo) (admission.Response, bool) {
+	if bypass.BypassesNamespace(nsLabels) {
+		logger.Info("Bypassing mutation", "label", bypass.LabelKey())
+		return admission.Allowed(bypassedReason), true
+	}
+
+	if pattern, ok := bypass.MatchUser(userInfo); ok {
+		logger.Info("Bypassing mutation", "username", userInfo.Username, "pattern", pattern)
+		return admission.Allowed(bypassedReason), true
+	}
+
+	return admission.Response{}, false
+}
@@ -0,0 +1,150 @@
+package webhook
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dana-team/env-route-ns-mutator/internal/utils"
+
+	. "github.com/onsi/gomega"
+	configv1 "github.com/openshift/api/config/v1"
+	routev1 "github.com/openshift/api/route/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	testclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func TestExclusions(t *testing.T) {
+	logger := ctrl.Log.WithName("webhook")
+
+	tests := []struct {
+		name            string
+		namespaces      string
+		namespaceLabels string
+		objectLabels    string
+		namespace       string
+		nsLabels        map[string]string
+		objLabels       map[string]string
+		excluded        bool
+		invalidConfig   bool
+	}{
+		{name: "noExclusions", namespace: testNamespace, excluded: false},
+		{name: "exactName", namespaces: "default," + testNamespace, namespace: testNamespace, excluded: true},
+		{name: "globName", namespaces: "openshift-*,kube-*", namespace: "openshift-console", excluded: true},
+		{name: "globNameNoMatch", namespaces: "openshift-*,kube-*", namespace: testNamespace, excluded: false},
+		{name: "namespaceLabelExists", namespaceLabels: "openshift.io/run-level", namespace: testNamespace, nsLabels: map[string]string{"openshift.io/run-level": "0"}, excluded: true},
+		{name: "namespaceLabelAnyRequirement", namespaceLabels: "tier in (platform),team=infra", namespace: testNamespace, nsLabels: map[string]string{"team": "infra"}, excluded: true},
+		{name: "namespaceLabelNoMatch", namespaceLabels: "tier in (platform)", namespace: testNamespace, nsLabels: map[string]string{"tier": "apps"}, excluded: false},
+		{name: "objectLabel", objectLabels: "app.kubernetes.io/managed-by=operator", namespace: testNamespace, objLabels: map[string]string{"app.kubernetes.io/managed-by": "operator"}, excluded: true},
+		{name: "invalidSelector", namespaceLabels: "tier in (", namespace: testNamespace, invalidConfig: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			t.Setenv(utils.ExcludedNamespacesEnv, tc.namespaces)
+			t.Setenv(utils.ExcludedNamespaceLabelsEnv, tc.namespaceLabels)
+			t.Setenv(utils.ExcludedObjectLabelsEnv, tc.objectLabels)
+
+			exclusions, err := utils.GetExclusions()
+			if tc.invalidConfig {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+
+			_, excludedName := excludedByName(logger, exclusions, tc.namespace)
+			_, excludedLabels := excludedByLabels(logger, exclusions, tc.nsLabels, tc.objLabels)
+			g.Expect(excludedName || excludedLabels).To(Equal(tc.excluded))
+		})
+	}
+}
+
+func TestBypass(t *testing.T) {
+	logger := ctrl.Log.WithName("webhook")
+
+	tests := []struct {
+		name     string
+		label    string
+		users    string
+		nsLabels map[string]string
+		username string
+		bypassed bool
+	}{
+		{name: "defaultLabel", nsLabels: map[string]string{bypassLabel: "true"}, bypassed: true},
+		{name: "defaultLabelFalse", nsLabels: map[string]string{bypassLabel: "false"}, bypassed: false},
+		{name: "configuredLabel", label: "example.com/bypass", nsLabels: map[string]string{"example.com/bypass": "true"}, bypassed: true},
+		{name: "defaultLabelWithConfiguredLabel", label: "example.com/bypass", nsLabels: map[string]string{bypassLabel: "true"}, bypassed: false},
+		{name: "user", users: "system:serviceaccount:ci:*, admin", username: "system:serviceaccount:ci:deployer", bypassed: true},
+		{name: "userNoMatch", users: "system:serviceaccount:ci:*", username: "developer", bypassed: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			t.Setenv(utils.BypassLabelEnv, tc.label)
+			t.Setenv(utils.BypassUsersEnv, tc.users)
+
+			_, ok := bypassed(logger, utils.GetBypass(), tc.nsLabels, authenticationv1.UserInfo{Username: tc.username})
+			g.Expect(ok).To(Equal(tc.bypassed))
+		})
+	}
+}
+
+func TestRouteMutatorBypass(t *testing.T) {
+	testScheme := runtime.NewScheme()
+	utilruntime.Must(scheme.AddToScheme(testScheme))
+	utilruntime.Must(routev1.AddToScheme(testScheme))
+	utilruntime.Must(configv1.Install(testScheme))
+
+	objects := []runtime.Object{
+		&configv1.Ingress{ObjectMeta: metav1.ObjectMeta{Name: "cluster"}, Spec: configv1.IngressSpec{Domain: clusterIngressDomain}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: testNamespace, Labels: map[string]string{utils.Key: env1, bypassLabel: "true"}}},
+	}
+	k8sClient := testclient.NewClientBuilder().WithScheme(testScheme).WithRuntimeObjects(objects...).
+		WithIndex(&routev1.Route{}, routeHostIndex, routeHostIndexer).
+		WithIndex(&networkingv1.Ingress{}, ingressHostIndex, ingressHostIndexer).
+		Build()
+
+	tests := []struct {
+		name     string
+		label    string
+		users    string
+		username string
+		bypassed bool
+	}{
+		{name: "bypassLabel", bypassed: true},
+		{name: "otherBypassLabel", label: "example.com/bypass", bypassed: false},
+		{name: "bypassUser", label: "example.com/bypass", users: "deployer", username: "deployer", bypassed: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			t.Setenv(utils.Env, env1)
+			t.Setenv(utils.BypassLabelEnv, tc.label)
+			t.Setenv(utils.BypassUsersEnv, tc.users)
+
+			rm := RouteMutator{Decoder: admission.NewDecoder(testScheme), Client: k8sClient}
+			route := &routev1.Route{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: testNamespace}}
+
+			response := rm.Handle(context.Background(), admissionRequest(g, route, nil, authenticationv1.UserInfo{Username: tc.username}))
+
+			g.Expect(response.Allowed).To(BeTrue())
+			if tc.bypassed {
+				g.Expect(response.Patches).To(BeEmpty())
+			} else {
+				g.Expect(response.Patches).To(ContainElement(HaveField("Path", "/spec/host")))
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package webhook
+
+import (
+	"context"
+	"slices"
+
+	"github.com/dana-team/env-route-ns-mutator/internal/utils"
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const (
+	GatewayAPIGroup = "gateway.networking.k8s.io"
+	gatewayKind     = "Gateway"
+)
+
+// GatewayGVK is the GroupVersionKind of Gateway API Gateways.
+var GatewayGVK = schema.GroupVersionKind{Group: GatewayAPIGroup, Version: "v1", Kind: gatewayKind}
+
+// GatewayRouteMutator is the struct used to mutate Gateway API routes (HTTPRoute, GRPCRoute and TLSRoute).
+// The routes are handled as unstructured objects, so the Gateway API CRDs are optional.
+type GatewayRouteMutator struct {
+	Decoder admission.Decoder
+	Client  client.Client
+}
+
+// +kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=gateways,verbs=get
+
+// +kubebuilder:webhook:path=/mutate-v1-gateway-route,mutating=true,failurePolicy=ignore,sideEffects=None,groups=gateway.networking.k8s.io,resources=httproutes;grpcroutes;tlsroutes,verbs=create,versions=v1;v1alpha2,name=gatewayroute.dana.io,admissionReviewVersions=v1;v1beta1
+
+func (r *GatewayRouteMutator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	logger := log.FromContext(ctx).WithName("GatewayRoute").WithValues("name", req.Name, "kind", req.Kind.Kind)
+	logger.Info("webhook request received")
+
+	return handleUnstructured(ctx, logger, req, r.Decoder, r.Client, r)
+}
+
+// handleInner implements the main mutating logic. It modifies the hostnames of a Gateway API
+// route based on environment data and cluster ingress information, and rewrites its
+// parentRefs to the Gateway of the environment when one is configured.
+func (r *GatewayRouteMutator) handleInner(ctx context.Context, logger logr.Logger, route *unstructured.Unstructured, clusterIngress string, environments []string, settings map[string]utils.EnvironmentSettings, labels map[string]string) error {
+	env, ok := utils.NamespaceEnvironment(labels, environments)
+	if !ok {
+		return nil
+	}
+	domain, err := utils.EnvironmentHostDomain(env, settings[env], labels)
+	if err != nil {
+		return err
+	}
+
+	hostnames, found, err := unstructured.NestedStringSlice(route.Object, "spec", "hostnames")
+	if err != nil {
+		return err
+	}
+	if found {
+		hostnames = utils.ModifyHostnames(logger, route.GetName(), route.GetNamespace(), hostnames, domain, clusterIngress)
+		if err := unstructured.SetNestedStringSlice(route.Object, hostnames, "spec", "hostnames"); err != nil {
+			return err
+		}
+	}
+
+	if gateway := settings[env].Gateway; gateway != nil {
+		return r.rewriteParentRefs(ctx, logger, route, *gateway)
+	}
+
+	return nil
+}
+
+// rewriteParentRefs points every Gateway parentRef of the route at the Gateway of the environment.
+// The sectionName is kept when the Gateway of the environment has a listener with that name,
+// and removed otherwise, and the port is removed, since it refers to the listeners of the
+// original Gateway. Refs which become identical are merged, since the Gateway API rejects
+// duplicate parentRefs.
+func (r *GatewayRouteMutator) rewriteParentRefs(ctx context.Context, logger logr.Logger, route *unstructured.Unstructured, gateway utils.GatewayRef) error {
+	parentRefs, found, err := unstructured.NestedSlice(route.Object, "spec", "parentRefs")
+	if err != nil || !found {
+		return err
+	}
+
+	namespace := gateway.Namespace
+	if len(namespace) == 0 {
+		namespace = route.GetNamespace()
+	}
+	listeners, err := r.gatewayListeners(ctx, gateway.Name, namespace)
+	if err != nil {
+		return err
+	}
+
+	rewritten := make([]interface{}, 0, len(parentRefs))
+	for _, ref := range parentRefs {
+		if parentRef, ok := ref.(map[string]interface{}); ok && isGatewayRef(parentRef) {
+			parentRef["name"] = gateway.Name
+			if len(gateway.Namespace) > 0 {
+				parentRef["namespace"] = gateway.Namespace
+			} else {
+				delete(parentRef, "namespace")
+			}
+			if sectionName, _, _ := unstructured.NestedString(parentRef, "sectionName"); !listeners[sectionName] {
+				delete(parentRef, "sectionName")
+			}
+			delete(parentRef, "port")
+		}
+
+		if !slices.ContainsFunc(rewritten, func(existing interface{}) bool { return equality.Semantic.DeepEqual(existing, ref) }) {
+			rewritten = append(rewritten, ref)
+		}
+	}
+
+	logger.Info("ParentRefs rewritten to the environment gateway", "gateway", gateway.Name)
+	return unstructured.SetNestedSlice(route.Object, rewritten, "spec", "parentRefs")
+}
+
+// isGatewayRef checks if a parentRef references a Gateway, which is the default group and kind.
+func isGatewayRef(parentRef map[string]interface{}) bool {
+	group, _, _ := unstructured.NestedString(parentRef, "group")
+	kind, _, _ := unstructured.NestedString(parentRef, "kind")
+	return (len(group) == 0 || group == GatewayAPIGroup) && (len(kind) == 0 || kind == gatewayKind)
+}
+
+// gatewayListeners returns the names of the listeners of a Gateway. A Gateway which does not
+// exist, or whose CRD is not installed, has no listeners.
+func (r *GatewayRouteMutator) gatewayListeners(ctx context.Context, name, namespace string) (map[string]bool, error) {
+	gateway := &unstructured.Unstructured{}
+	gateway.SetGroupVersionKind(GatewayGVK)
+	if err := r.Client.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, gateway); err != nil {
+		if apierrors.IsNotFound(err) || meta.IsNoMatchError(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	listeners, _, err := unstructured.NestedSlice(gateway.Object, "spec", "listeners")
+	if err != nil {
+		return nil, err
+	}
+
+	names := map[string]bool{}
+	for _, listener := range listeners {
+		if listener, ok := listener.(map[string]interface{}); ok {
+			if name, _, _ := unstructured.NestedString(listener, "name"); len(name) > 0 {
+				names[name] = true
+			}
+		}
+	}
+
+	return names, nil
+}
@@ -0,0 +1,164 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/dana-team/env-route-ns-mutator/internal/utils"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	testclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const envGateway = "env1-gateway"
+
+func TestGatewayRouteMutator(t *testing.T) {
+	logger := ctrl.Log.WithName("webhook")
+
+	environments := []string{env1, env2}
+	settings := map[string]utils.EnvironmentSettings{
+		env1: {Gateway: &utils.GatewayRef{Name: envGateway, Namespace: "gateways"}},
+	}
+
+	tests := []struct {
+		name               string
+		kind               string
+		hostnames          []interface{}
+		nsLabels           map[string]string
+		expectedHostnames  []string
+		expectedParentName string
+	}{
+		{name: "httpRouteWithDefaultDomain", kind: "HTTPRoute", hostnames: []interface{}{fmt.Sprintf("test1.%s", clusterIngressDomain)}, nsLabels: map[string]string{utils.Key: env1}, expectedHostnames: []string{fmt.Sprintf("test1.%s-%s", env1, clusterIngressDomain)}, expectedParentName: envGateway},
+		{name: "grpcRouteWithMultipleHostnames", kind: "GRPCRoute", hostnames: []interface{}{fmt.Sprintf("test2.%s", clusterIngressDomain), "test2.custom.com"}, nsLabels: map[string]string{utils.Key: env2}, expectedHostnames: []string{fmt.Sprintf("test2.%s-%s", env2, clusterIngressDomain), "test2.custom.com"}, expectedParentName: "default"},
+		{name: "tlsRouteWithMutatedHostname", kind: "TLSRoute", hostnames: []interface{}{fmt.Sprintf("test3.%s-%s", env1, clusterIngressDomain)}, nsLabels: map[string]string{utils.Key: env1}, expectedHostnames: []string{fmt.Sprintf("test3.%s-%s", env1, clusterIngressDomain)}, expectedParentName: envGateway},
+		{name: "httpRouteWithoutHostnames", kind: "HTTPRoute", nsLabels: map[string]string{utils.Key: env1}, expectedHostnames: nil, expectedParentName: envGateway},
+		{name: "httpRouteWithoutLabels", kind: "HTTPRoute", hostnames: []interface{}{fmt.Sprintf("test5.%s", clusterIngressDomain)}, nsLabels: map[string]string{}, expectedHostnames: []string{fmt.Sprintf("test5.%s", clusterIngressDomain)}, expectedParentName: "default"},
+	}
+
+	client := testclient.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			rm := GatewayRouteMutator{Decoder: admission.NewDecoder(scheme.Scheme), Client: client}
+
+			spec := map[string]interface{}{
+				"parentRefs": []interface{}{
+					map[string]interface{}{"name": "default", "sectionName": "https"},
+					map[string]interface{}{"group": "", "kind": "Service", "name": "mesh"},
+				},
+			}
+			if tc.hostnames != nil {
+				spec["hostnames"] = tc.hostnames
+			}
+			route := &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "gateway.networking.k8s.io/v1",
+				"kind":       tc.kind,
+				"metadata":   map[string]interface{}{"name": tc.name, "namespace": testNamespace},
+				"spec":       spec,
+			}}
+
+			g.Expect(rm.handleInner(context.Background(), logger, route, clusterIngressDomain, environments, settings, tc.nsLabels)).To(Succeed())
+
+			hostnames, _, err := unstructured.NestedStringSlice(route.Object, "spec", "hostnames")
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(hostnames).To(Equal(tc.expectedHostnames))
+
+			parentRefs, _, err := unstructured.NestedSlice(route.Object, "spec", "parentRefs")
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(parentRefs).To(HaveLen(2))
+			g.Expect(parentRefs[0]).To(HaveKeyWithValue("name", tc.expectedParentName))
+			if tc.expectedParentName == envGateway {
+				g.Expect(parentRefs[0]).To(HaveKeyWithValue("namespace", "gateways"))
+				g.Expect(parentRefs[0]).NotTo(HaveKey("sectionName"))
+			}
+			g.Expect(parentRefs[1]).To(HaveKeyWithValue("name", "mesh"))
+		})
+	}
+}
+
+func TestGatewayRouteMutatorParentRefs(t *testing.T) {
+	logger := ctrl.Log.WithName("webhook")
+
+	environments := []string{env1}
+	settings := map[string]utils.EnvironmentSettings{
+		env1: {Gateway: &utils.GatewayRef{Name: envGateway, Namespace: "gateways"}},
+	}
+	labels := map[string]string{utils.Key: env1}
+
+	testScheme := runtime.NewScheme()
+	utilruntime.Must(scheme.AddToScheme(testScheme))
+	testScheme.AddKnownTypeWithName(GatewayGVK, &unstructured.Unstructured{})
+
+	gateway := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"listeners": []interface{}{map[string]interface{}{"name": "https"}},
+		},
+	}}
+	gateway.SetGroupVersionKind(GatewayGVK)
+	gateway.SetName(envGateway)
+	gateway.SetNamespace("gateways")
+	client := testclient.NewClientBuilder().WithScheme(testScheme).WithObjects(gateway).Build()
+
+	envRef := map[string]interface{}{"name": envGateway, "namespace": "gateways"}
+	envHTTPSRef := map[string]interface{}{"name": envGateway, "namespace": "gateways", "sectionName": "https"}
+
+	tests := []struct {
+		name               string
+		parentRefs         []interface{}
+		expectedParentRefs []interface{}
+	}{
+		{
+			name: "twoListeners",
+			parentRefs: []interface{}{
+				map[string]interface{}{"name": "default", "sectionName": "https"},
+				map[string]interface{}{"name": "default", "sectionName": "http"},
+			},
+			expectedParentRefs: []interface{}{envHTTPSRef, envRef},
+		},
+		{
+			name: "twoGateways",
+			parentRefs: []interface{}{
+				map[string]interface{}{"name": "default", "sectionName": "https"},
+				map[string]interface{}{"name": "other", "namespace": "gateways", "sectionName": "https", "port": int64(443)},
+				map[string]interface{}{"group": "", "kind": "Service", "name": "mesh"},
+			},
+			expectedParentRefs: []interface{}{envHTTPSRef, map[string]interface{}{"group": "", "kind": "Service", "name": "mesh"}},
+		},
+		{
+			name: "listenersMissingFromGateway",
+			parentRefs: []interface{}{
+				map[string]interface{}{"name": "default", "sectionName": "http"},
+				map[string]interface{}{"name": "other", "port": int64(80)},
+			},
+			expectedParentRefs: []interface{}{envRef},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			rm := GatewayRouteMutator{Decoder: admission.NewDecoder(testScheme), Client: client}
+			route := &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "gateway.networking.k8s.io/v1",
+				"kind":       "HTTPRoute",
+				"metadata":   map[string]interface{}{"name": tc.name, "namespace": testNamespace},
+				"spec":       map[string]interface{}{"parentRefs": tc.parentRefs},
+			}}
+
+			g.Expect(rm.handleInner(context.Background(), logger, route, clusterIngressDomain, environments, settings, labels)).To(Succeed())
+
+			parentRefs, _, err := unstructured.NestedSlice(route.Object, "spec", "parentRefs")
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(parentRefs).To(Equal(tc.expectedParentRefs))
+		})
+	}
+}
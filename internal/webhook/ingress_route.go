@@ -0,0 +1,60 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	routev1 "github.com/openshift/api/route/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const ingressOwnerAuditAnnotation = "owner-ingress"
+
+// ingressOwner returns the owner reference of the Ingress a Route was generated from.
+func ingressOwner(route *routev1.Route) (metav1.OwnerReference, bool) {
+	for _, owner := range route.OwnerReferences {
+		if owner.Kind == "Ingress" && owner.APIVersion == networkingv1.SchemeGroupVersion.String() {
+			return owner, true
+		}
+	}
+
+	return metav1.OwnerReference{}, false
+}
+
+// verifyIngressRoute handles Routes generated from an Ingress by the ingress-to-route controller.
+// The host of such a Route was already set from the Ingress rule, which IngressMutator rewrote,
+// so the Route is never rewritten again; its host is only verified against the rules of the Ingress.
+// Since the owner reference is set by the client, a Route whose owner Ingress is missing, or whose
+// host matches no rule of it, is denied rather than let through unchanged.
+func verifyIngressRoute(ctx context.Context, logger logr.Logger, k8sClient client.Client, route *routev1.Route, owner metav1.OwnerReference) (admission.Response, error) {
+	ingress := networkingv1.Ingress{}
+	if err := k8sClient.Get(ctx, types.NamespacedName{Name: owner.Name, Namespace: route.Namespace}, &ingress); err != nil {
+		if apierrors.IsNotFound(err) {
+			logger.Info("Owner ingress not found, denying route", "ingress", owner.Name)
+			return admission.Denied(fmt.Sprintf("owner ingress %q not found", owner.Name)), nil
+		}
+		return admission.Response{}, err
+	}
+	if len(owner.UID) > 0 && owner.UID != ingress.UID {
+		logger.Info("Owner reference does not match the UID of the ingress, denying route", "ingress", owner.Name)
+		return admission.Denied(fmt.Sprintf("owner reference does not match the UID of ingress %q", owner.Name)), nil
+	}
+
+	for _, rule := range ingress.Spec.Rules {
+		if rule.Host == route.Spec.Host {
+			logger.Info("Route host matches the owner ingress, remains unchanged", "hostname", route.Spec.Host, "ingress", owner.Name)
+			response := admission.Allowed("route is generated from an ingress")
+			response.AuditAnnotations = map[string]string{ingressOwnerAuditAnnotation: owner.Name}
+			return response, nil
+		}
+	}
+
+	logger.Info("Route host does not match any rule of the owner ingress, denying route", "hostname", route.Spec.Host, "ingress", owner.Name)
+	return admission.Denied(fmt.Sprintf("host %q does not match any rule of owner ingress %q", route.Spec.Host, owner.Name)), nil
+}
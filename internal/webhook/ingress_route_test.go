@@ -0,0 +1,107 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/dana-team/env-route-ns-mutator/internal/utils"
+
+	. "github.com/onsi/gomega"
+	routev1 "github.com/openshift/api/route/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	testclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func TestIngressToRouteChain(t *testing.T) {
+	logger := ctrl.Log.WithName("webhook")
+
+	const ingressUID types.UID = "3f1c2a9e-6b1d-4c1e-9f2a-7d5b8e0c4a11"
+
+	environments := []string{env1, env2}
+	nsLabels := map[string]string{utils.Key: env1}
+
+	tests := []struct {
+		name          string
+		ingressHost   string
+		routeHost     func(mutatedIngressHost string) string
+		createIngress bool
+		ownerUID      types.UID
+		allowed       bool
+	}{
+		{name: "ingressWithDefaultDomain", ingressHost: fmt.Sprintf("test.%s", clusterIngressDomain), routeHost: func(h string) string { return h }, createIngress: true, allowed: true},
+		{name: "ingressWithEmptyHost", ingressHost: "", routeHost: func(h string) string { return h }, createIngress: true, allowed: true},
+		{name: "ingressWithCustomDomain", ingressHost: "test.custom.com", routeHost: func(h string) string { return h }, createIngress: true, allowed: true},
+		{name: "ownerWithMatchingUID", ingressHost: "test.custom.com", routeHost: func(h string) string { return h }, createIngress: true, ownerUID: ingressUID, allowed: true},
+		{name: "routeWithStaleHost", ingressHost: fmt.Sprintf("test.%s", clusterIngressDomain), routeHost: func(string) string { return fmt.Sprintf("test.%s", clusterIngressDomain) }, createIngress: true, allowed: false},
+		{name: "ingressNotFound", ingressHost: fmt.Sprintf("test.%s", clusterIngressDomain), routeHost: func(h string) string { return h }, createIngress: false, allowed: false},
+		{name: "forgedOwnerHost", ingressHost: "test.custom.com", routeHost: func(string) string { return "other.custom.com" }, createIngress: true, allowed: false},
+		{name: "forgedOwnerUID", ingressHost: "test.custom.com", routeHost: func(h string) string { return h }, createIngress: true, ownerUID: "forged", allowed: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			ingress := &networkingv1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{Name: tc.name, Namespace: testNamespace, UID: ingressUID},
+				Spec:       networkingv1.IngressSpec{Rules: []networkingv1.IngressRule{{Host: tc.ingressHost}}},
+			}
+
+			im := IngressMutator{Decoder: admission.NewDecoder(scheme.Scheme)}
+			g.Expect(im.handleInner(logger, ingress, clusterIngressDomain, environments, nil, nsLabels)).To(Succeed())
+			mutatedIngressHost := ingress.Spec.Rules[0].Host
+
+			builder := testclient.NewClientBuilder().WithScheme(scheme.Scheme)
+			if tc.createIngress {
+				builder = builder.WithObjects(ingress)
+			}
+			k8sClient := builder.Build()
+
+			routeHost := tc.routeHost(mutatedIngressHost)
+			route := &routev1.Route{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      fmt.Sprintf("%s-x7k2p", tc.name),
+					Namespace: testNamespace,
+					OwnerReferences: []metav1.OwnerReference{
+						{APIVersion: networkingv1.SchemeGroupVersion.String(), Kind: "Ingress", Name: tc.name, UID: tc.ownerUID, Controller: ptr.To(true)},
+					},
+				},
+				Spec: routev1.RouteSpec{Host: routeHost},
+			}
+
+			owner, ok := ingressOwner(route)
+			g.Expect(ok).To(BeTrue())
+
+			response, err := verifyIngressRoute(context.Background(), logger, k8sClient, route, owner)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(response.Allowed).To(Equal(tc.allowed))
+			g.Expect(response.Patches).To(BeEmpty())
+			g.Expect(route.Spec.Host).To(Equal(routeHost))
+			if tc.allowed {
+				g.Expect(response.AuditAnnotations).To(HaveKeyWithValue(ingressOwnerAuditAnnotation, tc.name))
+			}
+		})
+	}
+}
+
+func TestIngressOwner(t *testing.T) {
+	g := NewWithT(t)
+
+	route := &routev1.Route{ObjectMeta: metav1.ObjectMeta{
+		OwnerReferences: []metav1.OwnerReference{{APIVersion: "extensions/v1beta1", Kind: "Ingress", Name: "legacy"}},
+	}}
+	_, ok := ingressOwner(route)
+	g.Expect(ok).To(BeFalse())
+
+	route.OwnerReferences = append(route.OwnerReferences, metav1.OwnerReference{APIVersion: "networking.k8s.io/v1", Kind: "Ingress", Name: "test"})
+	owner, ok := ingressOwner(route)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(owner.Name).To(Equal("test"))
+}
@@ -0,0 +1,95 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/dana-team/env-route-ns-mutator/internal/config"
+	"github.com/dana-team/env-route-ns-mutator/internal/utils"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// IngressValidator is the struct used to validate Ingresses
+type IngressValidator struct {
+	Decoder admission.Decoder
+	Client  client.Client
+}
+
+// +kubebuilder:webhook:path=/validate-v1-ingress,mutating=false,failurePolicy=ignore,sideEffects=None,groups=networking.k8s.io,resources=ingresses,verbs=create;update,versions=v1,name=vingress.dana.io,admissionReviewVersions=v1;v1beta1
+
+func (r *IngressValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	logger := log.FromContext(ctx).WithName("IngressValidator").WithValues("name", req.Name)
+	logger.Info("webhook request received")
+
+	cfg, err := config.Current()
+	if err != nil {
+		logger.Error(err, "failed to get configuration")
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+
+	if response, excluded := excludedByName(logger, cfg.Exclusions, req.Namespace); excluded {
+		return response
+	}
+
+	ingress := networkingv1.Ingress{}
+	if err := r.Decoder.Decode(req, &ingress); err != nil {
+		logger.Error(err, "failed to decode ingress object")
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+
+	if response, owned := platformOwned(logger, cfg.PlatformRules, &ingress, req); owned {
+		return response
+	}
+
+	namespace := corev1.Namespace{}
+	if err := r.Client.Get(ctx, types.NamespacedName{Name: req.Namespace}, &namespace); err != nil {
+		logger.Error(err, "failed to get namespace object")
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+
+	if response, excluded := excludedByLabels(logger, cfg.Exclusions, namespace.Labels, ingress.Labels); excluded {
+		return response
+	}
+
+	if response, ok := bypassed(logger, cfg.Bypass, namespace.Labels, req.UserInfo); ok {
+		return response
+	}
+
+	environments, settings := cfg.Environments, cfg.EnvironmentSettings
+	return r.handleInner(logger, &ingress, environments, settings, namespace.Labels)
+}
+
+// handleInner implements the main validating logic. It rejects Ingresses which are not
+// served by the strict IngressClass of the environment, so that the class set by the
+// mutator on creation cannot be changed by an update.
+func (r *IngressValidator) handleInner(logger logr.Logger, ingress *networkingv1.Ingress, environments []string, settings map[string]utils.EnvironmentSettings, labels map[string]string) admission.Response {
+	env, ok := utils.NamespaceEnvironment(labels, environments)
+	if !ok {
+		return admission.Allowed("")
+	}
+
+	ingressClass := settings[env].IngressClass
+	if ingressClass == nil || !ingressClass.Strict {
+		return admission.Allowed("")
+	}
+
+	if _, ok := ingress.GetAnnotations()[legacyIngressClassAnnotation]; ok {
+		logger.Info("Denying ingress with the legacy ingress class annotation")
+		return admission.Denied(fmt.Sprintf("ingresses in environment %q must not set the %s annotation", env, legacyIngressClassAnnotation))
+	}
+
+	if ingress.Spec.IngressClassName == nil || *ingress.Spec.IngressClassName != ingressClass.Name {
+		logger.Info("Denying ingress with another ingress class")
+		return admission.Denied(fmt.Sprintf("ingresses in environment %q must set spec.ingressClassName to %q", env, ingressClass.Name))
+	}
+
+	return admission.Allowed("")
+}
@@ -0,0 +1,94 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/dana-team/env-route-ns-mutator/internal/utils"
+
+	. "github.com/onsi/gomega"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	testclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func TestIngressValidator(t *testing.T) {
+	logger := ctrl.Log.WithName("webhook")
+
+	environments := []string{env1, env2}
+	settings := map[string]utils.EnvironmentSettings{
+		env1: {IngressClass: &utils.IngressClass{Name: "env1-router", Strict: true}},
+		env2: {IngressClass: &utils.IngressClass{Name: "env2-router"}},
+	}
+
+	tests := []struct {
+		name        string
+		className   *string
+		legacyClass string
+		nsLabels    map[string]string
+		allowed     bool
+	}{
+		{name: "strictClass", className: ptr.To("env1-router"), nsLabels: map[string]string{utils.Key: env1}, allowed: true},
+		{name: "otherClass", className: ptr.To("default"), nsLabels: map[string]string{utils.Key: env1}, allowed: false},
+		{name: "noClass", nsLabels: map[string]string{utils.Key: env1}, allowed: false},
+		{name: "legacyClass", className: ptr.To("env1-router"), legacyClass: "default", nsLabels: map[string]string{utils.Key: env1}, allowed: false},
+		{name: "notStrict", className: ptr.To("default"), nsLabels: map[string]string{utils.Key: env2}, allowed: true},
+		{name: "withoutLabels", className: ptr.To("default"), nsLabels: map[string]string{}, allowed: true},
+	}
+
+	k8sClient := testclient.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			iv := IngressValidator{Decoder: admission.NewDecoder(scheme.Scheme), Client: k8sClient}
+			ingress := &networkingv1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{Name: tc.name, Namespace: testNamespace},
+				Spec:       networkingv1.IngressSpec{IngressClassName: tc.className},
+			}
+			if len(tc.legacyClass) > 0 {
+				ingress.Annotations = map[string]string{legacyIngressClassAnnotation: tc.legacyClass}
+			}
+
+			response := iv.handleInner(logger, ingress, environments, settings, tc.nsLabels)
+
+			g.Expect(response.Allowed).To(Equal(tc.allowed))
+		})
+	}
+}
+
+func TestIngressValidatorUpdate(t *testing.T) {
+	g := NewWithT(t)
+
+	t.Setenv(utils.Env, fmt.Sprintf("%s,%s", env1, env2))
+	t.Setenv(utils.EnvironmentSettingsEnv, fmt.Sprintf("{%s: {ingressClass: {name: env1-router, strict: true}}}", env1))
+
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: testNamespace, Labels: map[string]string{utils.Key: env1}}}
+	k8sClient := testclient.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(namespace).Build()
+	iv := IngressValidator{Decoder: admission.NewDecoder(scheme.Scheme), Client: k8sClient}
+
+	var oldIngress client.Object = &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Name: "ingress", Namespace: testNamespace},
+		Spec:       networkingv1.IngressSpec{IngressClassName: ptr.To("env1-router")},
+	}
+	ingress := &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Name: "ingress", Namespace: testNamespace},
+		Spec:       networkingv1.IngressSpec{IngressClassName: ptr.To("default")},
+	}
+
+	response := iv.Handle(context.Background(), admissionRequest(g, ingress, oldIngress, authenticationv1.UserInfo{Username: "developer"}))
+	g.Expect(response.Allowed).To(BeFalse())
+
+	ingress.Spec.IngressClassName = ptr.To("env1-router")
+	ingress.Labels = map[string]string{"app": "updated"}
+	response = iv.Handle(context.Background(), admissionRequest(g, ingress, oldIngress, authenticationv1.UserInfo{Username: "developer"}))
+	g.Expect(response.Allowed).To(BeTrue())
+}
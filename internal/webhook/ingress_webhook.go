@@ -3,10 +3,12 @@ package webhook
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 
 	"github.com/go-logr/logr"
 
+	"github.com/dana-team/env-route-ns-mutator/internal/config"
 	"github.com/dana-team/env-route-ns-mutator/internal/utils"
 
 	networkingv1 "k8s.io/api/networking/v1"
@@ -15,10 +17,15 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 )
 
+// legacyIngressClassAnnotation is the deprecated annotation that selects the class of an
+// Ingress. It cannot be set together with spec.ingressClassName.
+const legacyIngressClassAnnotation = "kubernetes.io/ingress.class"
+
 // IngressMutator is the struct used to mutate ingresses.
 type IngressMutator struct {
 	Decoder admission.Decoder
@@ -33,49 +40,147 @@ func (r *IngressMutator) Handle(ctx context.Context, req admission.Request) admi
 	logger := log.FromContext(ctx).WithName("Ingress").WithValues("name", req.Name)
 	logger.Info("webhook request received")
 
+	cfg, err := config.Current()
+	if err != nil {
+		logger.Error(err, "failed to get configuration")
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+
+	if response, excluded := excludedByName(logger, cfg.Exclusions, req.Namespace); excluded {
+		return response
+	}
+
 	ingress := networkingv1.Ingress{}
 	if err := r.Decoder.Decode(req, &ingress); err != nil {
 		logger.Error(err, "failed to decode ingress object")
 		return admission.Errored(http.StatusInternalServerError, err)
 	}
 
+	if response, owned := platformOwned(logger, cfg.PlatformRules, &ingress, req); owned {
+		return response
+	}
+
 	namespace := corev1.Namespace{}
 	if err := r.Client.Get(ctx, types.NamespacedName{Name: req.Namespace}, &namespace); err != nil {
 		logger.Error(err, "failed to get namespace object")
 		return admission.Errored(http.StatusInternalServerError, err)
 	}
 
+	if response, excluded := excludedByLabels(logger, cfg.Exclusions, namespace.Labels, ingress.Labels); excluded {
+		return response
+	}
+
+	if response, ok := bypassed(logger, cfg.Bypass, namespace.Labels, req.UserInfo); ok {
+		return response
+	}
+
 	clusterIngress, err := utils.GetClusterIngressDomain(ctx, r.Client)
 	if err != nil {
 		logger.Error(err, "failed to get cluster ingress")
 		return admission.Errored(http.StatusInternalServerError, err)
 	}
 
-	environments := utils.GetEnvironments()
-	r.handleInner(logger, &ingress, clusterIngress, environments, namespace.ObjectMeta.Labels)
+	environments, settings := cfg.Environments, cfg.EnvironmentSettings
+	if err := r.handleInner(logger, &ingress, clusterIngress, environments, settings, namespace.ObjectMeta.Labels); err != nil {
+		logger.Error(err, "failed to mutate ingress")
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	var warnings []string
+	if env, ok := utils.NamespaceEnvironment(namespace.Labels, environments); ok {
+		domain, err := utils.EnvironmentHostDomain(env, settings[env], namespace.Labels)
+		if err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		envDomain := fmt.Sprintf("%s-%s", domain, clusterIngress)
+		for i, rule := range ingress.Spec.Rules {
+			host, warning, err := resolveHost(ctx, logger, r.Client, rule.Host, ingress.Namespace, envDomain, settings[env].HostCollision)
+			if err != nil {
+				return hostErrorResponse(logger, err)
+			}
+			if host != rule.Host {
+				replaceTLSHost(&ingress, rule.Host, host)
+			}
+			ingress.Spec.Rules[i].Host = host
+			warnings = appendWarning(warnings, warning)
+		}
+
+		for _, host := range routerCertificateHosts(&ingress) {
+			warning, err := checkCoverage(ctx, logger, r.Client, host, envDomain, settings[env])
+			if err != nil {
+				return hostErrorResponse(logger, err)
+			}
+			warnings = appendWarning(warnings, warning)
+		}
+	}
 
 	marshaledIngress, err := json.Marshal(ingress)
 	if err != nil {
 		admission.Errored(http.StatusInternalServerError, err)
 	}
 
-	return admission.PatchResponseFromRaw(req.Object.Raw, marshaledIngress)
+	return withEnvironmentChain(admission.PatchResponseFromRaw(req.Object.Raw, marshaledIngress).WithWarnings(warnings...), namespace.Labels, environments, settings)
 }
 
-func (r *IngressMutator) handleInner(logger logr.Logger, ingress *networkingv1.Ingress, clusterIngress string, environments []string, namespaceLabels map[string]string) {
-	if utils.CheckBypass(namespaceLabels) {
-		logger.Info("Bypassing mutation")
-		return
+// handleInner implements the main mutating logic. It modifies the rule hosts and the TLS hosts
+// of an Ingress based on environment data and cluster ingress information, and applies the
+// defaults of the environment.
+func (r *IngressMutator) handleInner(logger logr.Logger, ingress *networkingv1.Ingress, clusterIngress string, environments []string, settings map[string]utils.EnvironmentSettings, namespaceLabels map[string]string) error {
+	env, ok := utils.NamespaceEnvironment(namespaceLabels, environments)
+	if !ok {
+		return nil
+	}
+	domain, err := utils.EnvironmentHostDomain(env, settings[env], namespaceLabels)
+	if err != nil {
+		return err
 	}
 
-	for _, env := range environments {
-		if namespaceLabels[utils.Key] == env {
-			for i, rule := range ingress.Spec.Rules {
-				ruleHost := utils.ModifyHostname(logger, ingress.Name, ingress.Namespace, rule.Host, env, clusterIngress)
-				ingress.Spec.Rules[i].Host = ruleHost
-			}
-			break
+	for i, rule := range ingress.Spec.Rules {
+		ruleHost := utils.ModifyHostname(logger, ingress.Name, ingress.Namespace, rule.Host, domain, clusterIngress)
+		ingress.Spec.Rules[i].Host = ruleHost
+	}
+	for i, tls := range ingress.Spec.TLS {
+		ingress.Spec.TLS[i].Hosts = utils.ModifyHostnames(logger, ingress.Name, ingress.Namespace, tls.Hosts, domain, clusterIngress)
+	}
+	if issuerAnnotations := settings[env].IssuerAnnotations; len(issuerAnnotations) > 0 {
+		ingress.SetAnnotations(utils.AppendDefaults(ingress.GetAnnotations(), issuerAnnotations))
+		logger.Info("successfully updated issuer annotations")
+	}
+	r.setIngressClass(logger, ingress, settings[env].IngressClass)
+
+	return nil
+}
 
+// replaceTLSHost replaces a host in the TLS entries of an Ingress, so that they keep
+// matching a rule host which was changed.
+func replaceTLSHost(ingress *networkingv1.Ingress, host, replacement string) {
+	for i := range ingress.Spec.TLS {
+		for j, tlsHost := range ingress.Spec.TLS[i].Hosts {
+			if tlsHost == host {
+				ingress.Spec.TLS[i].Hosts[j] = replacement
+			}
 		}
 	}
 }
+
+// setIngressClass sets the IngressClass of the environment on Ingresses without a class,
+// or on every Ingress when the class is strict, so that the Ingress is served by the
+// controller of the environment.
+func (r *IngressMutator) setIngressClass(logger logr.Logger, ingress *networkingv1.Ingress, ingressClass *utils.IngressClass) {
+	if ingressClass == nil {
+		return
+	}
+
+	_, hasLegacyClass := ingress.GetAnnotations()[legacyIngressClassAnnotation]
+	if !ingressClass.Strict && (ingress.Spec.IngressClassName != nil || hasLegacyClass) {
+		return
+	}
+
+	if hasLegacyClass {
+		annotations := ingress.GetAnnotations()
+		delete(annotations, legacyIngressClassAnnotation)
+		ingress.SetAnnotations(annotations)
+	}
+	ingress.Spec.IngressClassName = ptr.To(ingressClass.Name)
+	logger.Info("successfully set ingress class", "ingressClass", ingressClass.Name)
+}
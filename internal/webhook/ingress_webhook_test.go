@@ -11,6 +11,7 @@ import (
 	. "github.com/onsi/gomega"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
 	ctrl "sigs.k8s.io/controller-runtime"
 	testclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
@@ -40,7 +41,6 @@ func TestIngressMutator(t *testing.T) {
 		{name: "ingressWithCustomNameDefaultDomain", namespace: testNamespace, hostname: "test2", customDomain: "", defaultDomain: true, nsLabels: map[string]string{utils.Key: env1}, mutated: true},
 		{name: "ingressWithNoCustomNameNoDomain", namespace: testNamespace, hostname: "", customDomain: "", defaultDomain: false, nsLabels: map[string]string{utils.Key: env1}, mutated: true},
 		{name: "ingressWithoutLabels", namespace: testNamespace, hostname: "test5", customDomain: "", defaultDomain: true, nsLabels: map[string]string{}, mutated: false},
-		{name: "ingressWithBypassLabel", namespace: testNamespace, hostname: "test6", customDomain: "", defaultDomain: true, nsLabels: map[string]string{bypassLabel: "true", utils.Key: env1}, mutated: false},
 		{name: "ingressWithInvalidBypassLabel", namespace: testNamespace, hostname: "test7", customDomain: "", defaultDomain: true, nsLabels: map[string]string{bypassLabel: "false", utils.Key: env1}, mutated: true},
 		{name: "ingressWithMutatedHostname", namespace: testNamespace, hostname: "test8", customDomain: fmt.Sprintf("%s-%s", env1, clusterIngressDomain), defaultDomain: false, nsLabels: map[string]string{utils.Key: env1}, mutated: false},
 	}
@@ -80,7 +80,7 @@ func TestIngressMutator(t *testing.T) {
 				},
 			}
 
-			rm.handleInner(logger, &ingress, clusterIngressDomain, environments, tc.nsLabels)
+			g.Expect(rm.handleInner(logger, &ingress, clusterIngressDomain, environments, nil, tc.nsLabels)).To(Succeed())
 
 			mutatedHost := ""
 			if tc.mutated {
@@ -99,3 +99,115 @@ func TestIngressMutator(t *testing.T) {
 		})
 	}
 }
+
+func TestIngressMutatorTLSHosts(t *testing.T) {
+	g := NewWithT(t)
+	logger := ctrl.Log.WithName("webhook")
+
+	host := fmt.Sprintf("test.%s", clusterIngressDomain)
+	mutatedHost := fmt.Sprintf("test.%s-%s", env1, clusterIngressDomain)
+
+	rm := IngressMutator{Decoder: admission.NewDecoder(scheme.Scheme), Client: testclient.NewClientBuilder().WithScheme(scheme.Scheme).Build()}
+	ingress := networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Name: "tls", Namespace: testNamespace},
+		Spec: networkingv1.IngressSpec{
+			Rules: []networkingv1.IngressRule{{Host: host}},
+			TLS:   []networkingv1.IngressTLS{{Hosts: []string{host, "test.custom.com"}, SecretName: "certificate"}},
+		},
+	}
+
+	g.Expect(rm.handleInner(logger, &ingress, clusterIngressDomain, []string{env1}, nil, map[string]string{utils.Key: env1})).To(Succeed())
+
+	g.Expect(ingress.Spec.Rules[0].Host).To(Equal(mutatedHost))
+	g.Expect(ingress.Spec.TLS[0].Hosts).To(Equal([]string{mutatedHost, "test.custom.com"}))
+}
+
+func TestIngressMutatorIssuerAnnotations(t *testing.T) {
+	logger := ctrl.Log.WithName("webhook")
+
+	environments := []string{env1, env2}
+	settings := map[string]utils.EnvironmentSettings{
+		env1: {IssuerAnnotations: map[string]string{clusterIssuerAnnotation: env1Issuer}},
+	}
+
+	tests := []struct {
+		name                string
+		annotations         map[string]string
+		nsLabels            map[string]string
+		expectedAnnotations map[string]string
+	}{
+		{name: "ingressWithoutAnnotations", nsLabels: map[string]string{utils.Key: env1}, expectedAnnotations: map[string]string{clusterIssuerAnnotation: env1Issuer}},
+		{name: "ingressWithUserAnnotation", annotations: map[string]string{clusterIssuerAnnotation: "user-issuer"}, nsLabels: map[string]string{utils.Key: env1}, expectedAnnotations: map[string]string{clusterIssuerAnnotation: "user-issuer"}},
+		{name: "ingressWithoutLabels", nsLabels: map[string]string{}, expectedAnnotations: nil},
+	}
+
+	client := testclient.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			rm := IngressMutator{Decoder: admission.NewDecoder(scheme.Scheme), Client: client}
+			ingress := networkingv1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{Name: tc.name, Namespace: testNamespace, Annotations: tc.annotations},
+				Spec:       networkingv1.IngressSpec{Rules: []networkingv1.IngressRule{{Host: fmt.Sprintf("test.%s", clusterIngressDomain)}}},
+			}
+
+			g.Expect(rm.handleInner(logger, &ingress, clusterIngressDomain, environments, settings, tc.nsLabels)).To(Succeed())
+
+			g.Expect(ingress.GetAnnotations()).To(Equal(tc.expectedAnnotations))
+		})
+	}
+}
+
+func TestIngressMutatorIngressClass(t *testing.T) {
+	logger := ctrl.Log.WithName("webhook")
+
+	const (
+		envClass  = "env-router"
+		userClass = "user-router"
+	)
+
+	environments := []string{env1, env2}
+	settings := map[string]utils.EnvironmentSettings{
+		env1: {IngressClass: &utils.IngressClass{Name: envClass}},
+		env2: {IngressClass: &utils.IngressClass{Name: envClass, Strict: true}},
+	}
+
+	tests := []struct {
+		name                 string
+		ingressClassName     *string
+		annotations          map[string]string
+		nsLabels             map[string]string
+		expectedIngressClass *string
+		expectedAnnotations  map[string]string
+	}{
+		{name: "ingressWithoutClass", nsLabels: map[string]string{utils.Key: env1}, expectedIngressClass: ptr.To(envClass)},
+		{name: "ingressWithClass", ingressClassName: ptr.To(userClass), nsLabels: map[string]string{utils.Key: env1}, expectedIngressClass: ptr.To(userClass)},
+		{name: "ingressWithLegacyClass", annotations: map[string]string{legacyIngressClassAnnotation: userClass}, nsLabels: map[string]string{utils.Key: env1}, expectedIngressClass: nil, expectedAnnotations: map[string]string{legacyIngressClassAnnotation: userClass}},
+		{name: "strictIngressWithoutClass", nsLabels: map[string]string{utils.Key: env2}, expectedIngressClass: ptr.To(envClass)},
+		{name: "strictIngressWithClass", ingressClassName: ptr.To(userClass), nsLabels: map[string]string{utils.Key: env2}, expectedIngressClass: ptr.To(envClass)},
+		{name: "strictIngressWithLegacyClass", annotations: map[string]string{legacyIngressClassAnnotation: userClass}, nsLabels: map[string]string{utils.Key: env2}, expectedIngressClass: ptr.To(envClass), expectedAnnotations: map[string]string{}},
+		{name: "ingressWithoutLabels", nsLabels: map[string]string{}, expectedIngressClass: nil},
+	}
+
+	client := testclient.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			rm := IngressMutator{Decoder: admission.NewDecoder(scheme.Scheme), Client: client}
+			ingress := networkingv1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{Name: tc.name, Namespace: testNamespace, Annotations: tc.annotations},
+				Spec: networkingv1.IngressSpec{
+					IngressClassName: tc.ingressClassName,
+					Rules:            []networkingv1.IngressRule{{Host: fmt.Sprintf("test.%s", clusterIngressDomain)}},
+				},
+			}
+
+			g.Expect(rm.handleInner(logger, &ingress, clusterIngressDomain, environments, settings, tc.nsLabels)).To(Succeed())
+
+			g.Expect(ingress.Spec.IngressClassName).To(Equal(tc.expectedIngressClass))
+			g.Expect(ingress.GetAnnotations()).To(Equal(tc.expectedAnnotations))
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package webhook
+
+import (
+	"strings"
+
+	"github.com/dana-team/env-route-ns-mutator/internal/utils"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const environmentChainAuditAnnotation = "environment-chain"
+
+// withEnvironmentChain records the inheritance chain of the environment of a namespace in
+// an audit annotation, when the environment inherits from a parent.
+func withEnvironmentChain(response admission.Response, labels map[string]string, environments []string, settings map[string]utils.EnvironmentSettings) admission.Response {
+	env, ok := utils.NamespaceEnvironment(labels, environments)
+	if !ok || len(settings[env].Chain) < 2 {
+		return response
+	}
+
+	if response.AuditAnnotations == nil {
+		response.AuditAnnotations = map[string]string{}
+	}
+	response.AuditAnnotations[environmentChainAuditAnnotation] = strings.Join(settings[env].Chain, ",")
+
+	return response
+}
@@ -0,0 +1,84 @@
+package webhook
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/dana-team/env-route-ns-mutator/internal/utils"
+
+	. "github.com/onsi/gomega"
+	routev1 "github.com/openshift/api/route/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	testclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const inheritedSettings = `
+prod:
+  domain: production
+  routeAnnotations: {team: platform, tier: gold}
+prod-eu:
+  parent: prod
+  routeAnnotations: {tier: silver}
+prod-eu-west:
+  parent: prod-eu
+  domain: euw
+`
+
+func TestInheritance(t *testing.T) {
+	logger := ctrl.Log.WithName("webhook")
+
+	environments := []string{"prod", "prod-eu", "prod-eu-west"}
+
+	tests := []struct {
+		name        string
+		env         string
+		domain      string
+		annotations map[string]string
+		chain       string
+	}{
+		{name: "root", env: "prod", domain: "production", annotations: map[string]string{"team": "platform", "tier": "gold"}, chain: ""},
+		{name: "child", env: "prod-eu", domain: "production", annotations: map[string]string{"team": "platform", "tier": "silver"}, chain: "prod-eu,prod"},
+		{name: "grandchild", env: "prod-eu-west", domain: "euw", annotations: map[string]string{"team": "platform", "tier": "silver"}, chain: "prod-eu-west,prod-eu,prod"},
+	}
+
+	t.Setenv(utils.EnvironmentSettingsEnv, inheritedSettings)
+	settings, err := utils.GetEnvironmentSettings()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := testclient.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			rm := RouteMutator{Decoder: admission.NewDecoder(scheme.Scheme), Client: client}
+			route := &routev1.Route{ObjectMeta: metav1.ObjectMeta{Name: tc.name, Namespace: testNamespace}}
+			labels := map[string]string{utils.Key: tc.env}
+
+			g.Expect(rm.handleInner(logger, route, clusterIngressDomain, environments, settings, labels)).To(Succeed())
+
+			g.Expect(route.Spec.Host).To(Equal(fmt.Sprintf("%s-%s.%s-%s", tc.name, testNamespace, tc.domain, clusterIngressDomain)))
+			g.Expect(route.Annotations).To(Equal(tc.annotations))
+
+			response := withEnvironmentChain(admission.Allowed(""), labels, environments, settings)
+			if len(tc.chain) > 0 {
+				g.Expect(response.AuditAnnotations).To(HaveKeyWithValue(environmentChainAuditAnnotation, tc.chain))
+			} else {
+				g.Expect(response.AuditAnnotations).NotTo(HaveKey(environmentChainAuditAnnotation))
+			}
+		})
+	}
+}
+
+func TestInheritanceCycle(t *testing.T) {
+	g := NewWithT(t)
+
+	t.Setenv(utils.EnvironmentSettingsEnv, "{env1: {parent: env2}, env2: {parent: env1}}")
+	_, err := utils.GetEnvironmentSettings()
+
+	g.Expect(err).To(MatchError(ContainSubstring("inheritance cycle")))
+}
@@ -0,0 +1,94 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/dana-team/env-route-ns-mutator/internal/utils"
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const (
+	IstioNetworkingGroup = "networking.istio.io"
+	virtualServiceKind   = "VirtualService"
+	istioGatewayKind     = "Gateway"
+)
+
+// IstioMutator is the struct used to mutate Istio VirtualServices and Gateways.
+// The objects are handled as unstructured objects, so the Istio CRDs are optional.
+type IstioMutator struct {
+	Decoder admission.Decoder
+	Client  client.Client
+}
+
+// +kubebuilder:webhook:path=/mutate-v1-istio,mutating=true,failurePolicy=ignore,sideEffects=None,groups=networking.istio.io,resources=virtualservices;gateways,verbs=create,versions=v1;v1beta1;v1alpha3,name=istio.dana.io,admissionReviewVersions=v1;v1beta1
+
+func (r *IstioMutator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	logger := log.FromContext(ctx).WithName("Istio").WithValues("name", req.Name, "kind", req.Kind.Kind)
+	logger.Info("webhook request received")
+
+	return handleUnstructured(ctx, logger, req, r.Decoder, r.Client, r)
+}
+
+// handleInner implements the main mutating logic. It modifies the hosts of a VirtualService
+// or of the servers of a Gateway based on environment data and cluster ingress information.
+func (r *IstioMutator) handleInner(_ context.Context, logger logr.Logger, obj *unstructured.Unstructured, clusterIngress string, environments []string, settings map[string]utils.EnvironmentSettings, labels map[string]string) error {
+	env, ok := utils.NamespaceEnvironment(labels, environments)
+	if !ok {
+		return nil
+	}
+	domain, err := utils.EnvironmentHostDomain(env, settings[env], labels)
+	if err != nil {
+		return err
+	}
+
+	switch obj.GetKind() {
+	case virtualServiceKind:
+		return r.modifyHosts(logger, obj.Object, obj.GetName(), obj.GetNamespace(), domain, clusterIngress, "spec", "hosts")
+	case istioGatewayKind:
+		servers, found, err := unstructured.NestedSlice(obj.Object, "spec", "servers")
+		if err != nil || !found {
+			return err
+		}
+		for i, server := range servers {
+			serverMap, ok := server.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("spec.servers[%d] is not an object", i)
+			}
+			if err := r.modifyHosts(logger, serverMap, obj.GetName(), obj.GetNamespace(), domain, clusterIngress, "hosts"); err != nil {
+				return err
+			}
+		}
+		return unstructured.SetNestedSlice(obj.Object, servers, "spec", "servers")
+	}
+
+	return nil
+}
+
+// modifyHosts modifies the host list at the given path. A host may be prefixed by a
+// namespace, as in the "<namespace>/<host>" form of Gateway hosts, in which case only
+// the host part is modified.
+func (r *IstioMutator) modifyHosts(logger logr.Logger, obj map[string]interface{}, name, namespace, domain, clusterIngress string, fields ...string) error {
+	hosts, found, err := unstructured.NestedStringSlice(obj, fields...)
+	if err != nil || !found {
+		return err
+	}
+
+	for i, host := range hosts {
+		prefix := ""
+		if index := strings.Index(host, "/"); index >= 0 {
+			prefix, host = host[:index+1], host[index+1:]
+		}
+		if len(host) == 0 {
+			continue
+		}
+		hosts[i] = prefix + utils.ModifyHostname(logger, name, namespace, host, domain, clusterIngress)
+	}
+
+	return unstructured.SetNestedStringSlice(obj, hosts, fields...)
+}
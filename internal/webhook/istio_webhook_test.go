@@ -0,0 +1,78 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/dana-team/env-route-ns-mutator/internal/utils"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	testclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func TestIstioMutator(t *testing.T) {
+	logger := ctrl.Log.WithName("webhook")
+
+	environments := []string{env1, env2}
+	defaultHost := fmt.Sprintf("test.%s", clusterIngressDomain)
+	envHost := fmt.Sprintf("test.%s-%s", env1, clusterIngressDomain)
+
+	tests := []struct {
+		name          string
+		kind          string
+		hosts         []string
+		nsLabels      map[string]string
+		expectedHosts []string
+	}{
+		{name: "virtualServiceWithDefaultDomain", kind: virtualServiceKind, hosts: []string{defaultHost, "reviews", "test.custom.com"}, nsLabels: map[string]string{utils.Key: env1}, expectedHosts: []string{envHost, "reviews", "test.custom.com"}},
+		{name: "virtualServiceWithMutatedHost", kind: virtualServiceKind, hosts: []string{envHost}, nsLabels: map[string]string{utils.Key: env1}, expectedHosts: []string{envHost}},
+		{name: "virtualServiceWithoutLabels", kind: virtualServiceKind, hosts: []string{defaultHost}, nsLabels: map[string]string{}, expectedHosts: []string{defaultHost}},
+		{name: "gatewayWithDefaultDomain", kind: istioGatewayKind, hosts: []string{defaultHost, "*"}, nsLabels: map[string]string{utils.Key: env1}, expectedHosts: []string{envHost, "*"}},
+		{name: "gatewayWithNamespacedHosts", kind: istioGatewayKind, hosts: []string{"apps/" + defaultHost, "./" + defaultHost, "*/*"}, nsLabels: map[string]string{utils.Key: env1}, expectedHosts: []string{"apps/" + envHost, "./" + envHost, "*/*"}},
+	}
+
+	client := testclient.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			rm := IstioMutator{Decoder: admission.NewDecoder(scheme.Scheme), Client: client}
+
+			hosts := make([]interface{}, 0, len(tc.hosts))
+			for _, host := range tc.hosts {
+				hosts = append(hosts, host)
+			}
+
+			spec := map[string]interface{}{"hosts": hosts}
+			if tc.kind == istioGatewayKind {
+				spec = map[string]interface{}{"servers": []interface{}{map[string]interface{}{"hosts": hosts}}}
+			}
+			obj := &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "networking.istio.io/v1",
+				"kind":       tc.kind,
+				"metadata":   map[string]interface{}{"name": tc.name, "namespace": testNamespace},
+				"spec":       spec,
+			}}
+
+			g.Expect(rm.handleInner(context.Background(), logger, obj, clusterIngressDomain, environments, nil, tc.nsLabels)).To(Succeed())
+
+			var mutatedHosts []string
+			if tc.kind == istioGatewayKind {
+				servers, _, err := unstructured.NestedSlice(obj.Object, "spec", "servers")
+				g.Expect(err).NotTo(HaveOccurred())
+				mutatedHosts, _, err = unstructured.NestedStringSlice(servers[0].(map[string]interface{}), "hosts")
+				g.Expect(err).NotTo(HaveOccurred())
+			} else {
+				var err error
+				mutatedHosts, _, err = unstructured.NestedStringSlice(obj.Object, "spec", "hosts")
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+			g.Expect(mutatedHosts).To(Equal(tc.expectedHosts))
+		})
+	}
+}
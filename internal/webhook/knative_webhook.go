@@ -0,0 +1,74 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/dana-team/env-route-ns-mutator/internal/utils"
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const (
+	KnativeServingGroup      = "serving.knative.dev"
+	knativeServiceKind       = "Service"
+	knativeDomainMappingKind = "DomainMapping"
+	knativeAnnotationPrefix  = KnativeServingGroup + "/"
+)
+
+// KnativeMutator is the struct used to mutate Knative Services and DomainMappings.
+// The objects are handled as unstructured objects, so Knative is optional.
+type KnativeMutator struct {
+	Decoder admission.Decoder
+	Client  client.Client
+}
+
+// +kubebuilder:webhook:path=/mutate-v1-knative,mutating=true,failurePolicy=ignore,sideEffects=None,groups=serving.knative.dev,resources=services;domainmappings,verbs=create,versions=v1;v1beta1;v1alpha1,name=knative.dana.io,admissionReviewVersions=v1;v1beta1
+
+func (r *KnativeMutator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	logger := log.FromContext(ctx).WithName("Knative").WithValues("name", req.Name, "kind", req.Kind.Kind)
+	logger.Info("webhook request received")
+
+	return handleUnstructured(ctx, logger, req, r.Decoder, r.Client, r)
+}
+
+// handleInner implements the main mutating logic. A Knative Service is labeled with its
+// environment, so that a config-domain selector on the environment label gives it the
+// domain of the environment, and the hosts in its serving.knative.dev annotations are modified.
+// A DomainMapping is named after its host, which cannot be changed, so a DomainMapping
+// whose host does not belong to the environment is rejected.
+func (r *KnativeMutator) handleInner(_ context.Context, logger logr.Logger, obj *unstructured.Unstructured, clusterIngress string, environments []string, settings map[string]utils.EnvironmentSettings, labels map[string]string) error {
+	env, ok := utils.NamespaceEnvironment(labels, environments)
+	if !ok {
+		return nil
+	}
+	domain, err := utils.EnvironmentHostDomain(env, settings[env], labels)
+	if err != nil {
+		return err
+	}
+
+	switch obj.GetKind() {
+	case knativeServiceKind:
+		obj.SetLabels(utils.AppendLabels(obj.GetLabels(), map[string]string{utils.Key: env}))
+
+		annotations := obj.GetAnnotations()
+		for key, value := range annotations {
+			if strings.HasPrefix(key, knativeAnnotationPrefix) && strings.Contains(value, clusterIngress) {
+				annotations[key] = utils.ModifyHostname(logger, obj.GetName(), obj.GetNamespace(), value, domain, clusterIngress)
+			}
+		}
+		obj.SetAnnotations(annotations)
+		logger.Info("successfully updated knative service")
+	case knativeDomainMappingKind:
+		host := utils.ModifyHostname(logger, obj.GetName(), obj.GetNamespace(), obj.GetName(), domain, clusterIngress)
+		if host != obj.GetName() {
+			return fmt.Errorf("domain mapping %q is not in environment %q, use %q instead", obj.GetName(), env, host)
+		}
+	}
+
+	return nil
+}
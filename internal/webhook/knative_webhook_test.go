@@ -0,0 +1,76 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/dana-team/env-route-ns-mutator/internal/utils"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	testclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const knativeDomainAnnotation = "serving.knative.dev/domain"
+
+func TestKnativeMutator(t *testing.T) {
+	logger := ctrl.Log.WithName("webhook")
+
+	environments := []string{env1, env2}
+	defaultHost := fmt.Sprintf("test.%s", clusterIngressDomain)
+	envHost := fmt.Sprintf("test.%s-%s", env1, clusterIngressDomain)
+
+	tests := []struct {
+		name                string
+		kind                string
+		objectName          string
+		annotations         map[string]string
+		nsLabels            map[string]string
+		expectedLabel       string
+		expectedAnnotations map[string]string
+		denied              bool
+	}{
+		{name: "serviceInEnvironment", kind: knativeServiceKind, objectName: "test", nsLabels: map[string]string{utils.Key: env1}, expectedLabel: env1},
+		{name: "serviceWithDomainAnnotation", kind: knativeServiceKind, objectName: "test", annotations: map[string]string{knativeDomainAnnotation: defaultHost, "serving.knative.dev/creator": "developer"}, nsLabels: map[string]string{utils.Key: env1}, expectedLabel: env1, expectedAnnotations: map[string]string{knativeDomainAnnotation: envHost, "serving.knative.dev/creator": "developer"}},
+		{name: "serviceWithOtherAnnotation", kind: knativeServiceKind, objectName: "test", annotations: map[string]string{"example.com/host": defaultHost}, nsLabels: map[string]string{utils.Key: env1}, expectedLabel: env1, expectedAnnotations: map[string]string{"example.com/host": defaultHost}},
+		{name: "serviceWithoutLabels", kind: knativeServiceKind, objectName: "test", nsLabels: map[string]string{}, expectedLabel: ""},
+		{name: "domainMappingInEnvironment", kind: knativeDomainMappingKind, objectName: envHost, nsLabels: map[string]string{utils.Key: env1}, denied: false},
+		{name: "domainMappingWithCustomDomain", kind: knativeDomainMappingKind, objectName: "test.custom.com", nsLabels: map[string]string{utils.Key: env1}, denied: false},
+		{name: "domainMappingWithDefaultDomain", kind: knativeDomainMappingKind, objectName: defaultHost, nsLabels: map[string]string{utils.Key: env1}, denied: true},
+		{name: "domainMappingWithoutLabels", kind: knativeDomainMappingKind, objectName: defaultHost, nsLabels: map[string]string{}, denied: false},
+	}
+
+	client := testclient.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			rm := KnativeMutator{Decoder: admission.NewDecoder(scheme.Scheme), Client: client}
+
+			obj := &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "serving.knative.dev/v1",
+				"kind":       tc.kind,
+				"metadata":   map[string]interface{}{"name": tc.objectName, "namespace": testNamespace},
+			}}
+			obj.SetAnnotations(tc.annotations)
+
+			err := rm.handleInner(context.Background(), logger, obj, clusterIngressDomain, environments, nil, tc.nsLabels)
+			if tc.denied {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+
+			if tc.kind == knativeServiceKind {
+				g.Expect(obj.GetLabels()[utils.Key]).To(Equal(tc.expectedLabel))
+				if tc.expectedAnnotations != nil {
+					g.Expect(obj.GetAnnotations()).To(Equal(tc.expectedAnnotations))
+				}
+			}
+		})
+	}
+}
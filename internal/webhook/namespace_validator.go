@@ -0,0 +1,132 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/dana-team/env-route-ns-mutator/internal/config"
+	"github.com/dana-team/env-route-ns-mutator/internal/utils"
+	"github.com/go-logr/logr"
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const (
+	environmentLabelAuditAnnotation             = "environment-label"
+	environmentLabelAuthorizedByAuditAnnotation = "environment-label-authorized-by"
+	environmentLabelDeniedAuditAnnotation       = "environment-label-denied"
+)
+
+// NamespaceValidator is the struct used to validate Namespaces
+type NamespaceValidator struct {
+	Decoder admission.Decoder
+	Client  client.Client
+}
+
+// +kubebuilder:webhook:path=/validate-v1-namespace,mutating=false,failurePolicy=fail,sideEffects=None,groups="",resources=namespaces,verbs=create;update,versions=v1,name=vnamespace.dana.io,admissionReviewVersions=v1;v1beta1
+
+func (r *NamespaceValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	logger := log.FromContext(ctx).WithName("NamespaceValidator").WithValues("name", req.Name)
+	logger.Info("webhook request received")
+
+	cfg, err := config.Current()
+	if err != nil {
+		logger.Error(err, "failed to get configuration")
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+
+	if response, excluded := excludedByName(logger, cfg.Exclusions, req.Name); excluded {
+		return response
+	}
+
+	namespace := corev1.Namespace{}
+	if err := r.Decoder.Decode(req, &namespace); err != nil {
+		logger.Error(err, "failed to decode namespace object")
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+
+	var oldNamespace *corev1.Namespace
+	if req.Operation == admissionv1.Update {
+		oldNamespace = &corev1.Namespace{}
+		if err := r.Decoder.DecodeRaw(req.OldObject, oldNamespace); err != nil {
+			logger.Error(err, "failed to decode old namespace object")
+			return admission.Errored(http.StatusInternalServerError, err)
+		}
+	}
+
+	// The label exclusions are taken from the old Namespace, so that a requester cannot
+	// exclude a Namespace in the same request that sets its environment label.
+	if oldNamespace != nil {
+		if response, excluded := excludedByLabels(logger, cfg.Exclusions, oldNamespace.Labels, oldNamespace.Labels); excluded {
+			return response
+		}
+	}
+
+	environments, settings := cfg.Environments, cfg.EnvironmentSettings
+	adminGroups := cfg.EnvironmentAdminGroups
+	return r.handleInner(logger, &namespace, oldNamespace, environments, settings, adminGroups, req.UserInfo)
+}
+
+// handleInner implements the main validating logic. Setting or changing the environment
+// label of a Namespace is allowed only when the label matches the environment tolerated by
+// the defaultTolerations annotation, or when the requester belongs to an admin group.
+// Removing the label is always allowed.
+func (r *NamespaceValidator) handleInner(logger logr.Logger, namespace, oldNamespace *corev1.Namespace, environments []string, settings map[string]utils.EnvironmentSettings, adminGroups []string, userInfo authenticationv1.UserInfo) admission.Response {
+	env, ok := utils.NamespaceEnvironment(namespace.GetLabels(), environments)
+	if !ok || (oldNamespace != nil && oldNamespace.GetLabels()[utils.Key] == env) {
+		return admission.Allowed("")
+	}
+
+	if group, ok := utils.MatchEnvironmentAdmin(adminGroups, userInfo); ok {
+		logger.Info("Environment label set by an environment admin", "environment", env, "group", group)
+		return allowedEnvironmentLabel(env, "group:"+group)
+	}
+
+	if annotationEnv, ok := annotationEnvironment(namespace, environments, settings); ok && annotationEnv == env {
+		return allowedEnvironmentLabel(env, DefaultSchedulerAnnotation)
+	}
+
+	logger.Info("Denying unauthorized environment label", "environment", env, "username", userInfo.Username)
+	response := admission.Denied(fmt.Sprintf("label %s=%s is allowed only when annotation %s tolerates environment %q, or for members of the groups %s",
+		utils.Key, env, DefaultSchedulerAnnotation, env, strings.Join(adminGroups, ", ")))
+	response.AuditAnnotations = map[string]string{
+		environmentLabelAuditAnnotation:       env,
+		environmentLabelDeniedAuditAnnotation: userInfo.Username,
+	}
+
+	return response
+}
+
+// annotationEnvironment returns the environment tolerated by the defaultTolerations
+// annotation of a Namespace.
+func annotationEnvironment(namespace *corev1.Namespace, environments []string, settings map[string]utils.EnvironmentSettings) (string, bool) {
+	value, ok := namespace.GetAnnotations()[DefaultSchedulerAnnotation]
+	if !ok {
+		return "", false
+	}
+
+	tolerations, err := utils.ParseTolerations(value)
+	if err != nil {
+		return "", false
+	}
+
+	return tolerationsEnvironment(tolerations, environments, settings)
+}
+
+// allowedEnvironmentLabel returns an allowed response which records the environment label
+// and what authorized it in audit annotations.
+func allowedEnvironmentLabel(env, authority string) admission.Response {
+	response := admission.Allowed("")
+	response.AuditAnnotations = map[string]string{
+		environmentLabelAuditAnnotation:             env,
+		environmentLabelAuthorizedByAuditAnnotation: authority,
+	}
+
+	return response
+}
@@ -0,0 +1,147 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/dana-team/env-route-ns-mutator/internal/utils"
+
+	. "github.com/onsi/gomega"
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	testclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func TestNamespaceValidator(t *testing.T) {
+	logger := ctrl.Log.WithName("webhook")
+
+	const adminGroup = "environment-admins"
+
+	environments := []string{env1, env2}
+	adminGroups := []string{"system:masters", adminGroup}
+
+	env1Tolerations := map[string]string{DefaultSchedulerAnnotation: fmt.Sprintf(`[{"key":"%s","operator":"Exists","effect":"NoSchedule"}]`, env1)}
+	developer := authenticationv1.UserInfo{Username: "developer", Groups: []string{"system:authenticated"}}
+	admin := authenticationv1.UserInfo{Username: "admin", Groups: []string{"system:authenticated", adminGroup}}
+
+	tests := []struct {
+		name          string
+		oldLabels     map[string]string
+		labels        map[string]string
+		annotations   map[string]string
+		userInfo      authenticationv1.UserInfo
+		create        bool
+		allowed       bool
+		expectedAudit string
+	}{
+		{name: "createMatchingAnnotation", create: true, labels: map[string]string{utils.Key: env1}, annotations: env1Tolerations, userInfo: developer, allowed: true, expectedAudit: DefaultSchedulerAnnotation},
+		{name: "createWithoutAnnotation", create: true, labels: map[string]string{utils.Key: env1}, userInfo: developer, allowed: false},
+		{name: "createByAdmin", create: true, labels: map[string]string{utils.Key: env2}, userInfo: admin, allowed: true, expectedAudit: "group:" + adminGroup},
+		{name: "changeToOtherEnvironment", oldLabels: map[string]string{utils.Key: env2}, labels: map[string]string{utils.Key: env1}, userInfo: developer, allowed: false},
+		{name: "changeNotMatchingAnnotation", oldLabels: map[string]string{utils.Key: env1}, labels: map[string]string{utils.Key: env2}, annotations: env1Tolerations, userInfo: developer, allowed: false},
+		{name: "changeByAdmin", oldLabels: map[string]string{utils.Key: env1}, labels: map[string]string{utils.Key: env2}, annotations: env1Tolerations, userInfo: admin, allowed: true, expectedAudit: "group:" + adminGroup},
+		{name: "labelUnchanged", oldLabels: map[string]string{utils.Key: env2}, labels: map[string]string{utils.Key: env2}, userInfo: developer, allowed: true},
+		{name: "labelRemoved", oldLabels: map[string]string{utils.Key: env2}, labels: map[string]string{}, userInfo: developer, allowed: true},
+		{name: "labelNotAnEnvironment", create: true, labels: map[string]string{utils.Key: "other"}, userInfo: developer, allowed: true},
+	}
+
+	client := testclient.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			rv := NamespaceValidator{Decoder: admission.NewDecoder(scheme.Scheme), Client: client}
+			namespace := &corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{Name: tc.name, Labels: tc.labels, Annotations: tc.annotations},
+			}
+			var oldNamespace *corev1.Namespace
+			if !tc.create {
+				oldNamespace = &corev1.Namespace{
+					ObjectMeta: metav1.ObjectMeta{Name: tc.name, Labels: tc.oldLabels, Annotations: tc.annotations},
+				}
+			}
+
+			response := rv.handleInner(logger, namespace, oldNamespace, environments, nil, adminGroups, tc.userInfo)
+
+			g.Expect(response.Allowed).To(Equal(tc.allowed))
+			if !tc.allowed {
+				g.Expect(response.AuditAnnotations).To(HaveKeyWithValue(environmentLabelDeniedAuditAnnotation, tc.userInfo.Username))
+			}
+			if len(tc.expectedAudit) > 0 {
+				g.Expect(response.AuditAnnotations).To(HaveKeyWithValue(environmentLabelAuthorizedByAuditAnnotation, tc.expectedAudit))
+			}
+		})
+	}
+}
+
+func TestNamespaceValidatorExclusions(t *testing.T) {
+	const excludedLabel = "tier"
+
+	t.Setenv(utils.Env, fmt.Sprintf("%s,%s", env1, env2))
+	t.Setenv(utils.ExcludedNamespaceLabelsEnv, excludedLabel+" in (platform)")
+
+	developer := authenticationv1.UserInfo{Username: "developer", Groups: []string{"system:authenticated"}}
+	excluded := map[string]string{excludedLabel: "platform"}
+
+	tests := []struct {
+		name      string
+		oldLabels map[string]string
+		labels    map[string]string
+		create    bool
+		allowed   bool
+	}{
+		{name: "createWithExcludedLabel", create: true, labels: map[string]string{utils.Key: env1, excludedLabel: "platform"}, allowed: false},
+		{name: "addExcludedLabel", oldLabels: map[string]string{}, labels: map[string]string{utils.Key: env1, excludedLabel: "platform"}, allowed: false},
+		{name: "alreadyExcluded", oldLabels: excluded, labels: map[string]string{utils.Key: env1, excludedLabel: "platform"}, allowed: true},
+	}
+
+	k8sClient := testclient.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			rv := NamespaceValidator{Decoder: admission.NewDecoder(scheme.Scheme), Client: k8sClient}
+			namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: tc.name, Labels: tc.labels}}
+			var oldNamespace client.Object
+			if !tc.create {
+				oldNamespace = &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: tc.name, Labels: tc.oldLabels}}
+			}
+
+			response := rv.Handle(context.Background(), admissionRequest(g, namespace, oldNamespace, developer))
+
+			g.Expect(response.Allowed).To(Equal(tc.allowed))
+		})
+	}
+}
+
+// admissionRequest returns an admission request for an object, which is an update when
+// the old object is set.
+func admissionRequest(g Gomega, obj, oldObj client.Object, userInfo authenticationv1.UserInfo) admission.Request {
+	raw, err := json.Marshal(obj)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	req := admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+		Operation: admissionv1.Create,
+		Name:      obj.GetName(),
+		Namespace: obj.GetNamespace(),
+		Object:    runtime.RawExtension{Raw: raw},
+		UserInfo:  userInfo,
+	}}
+	if oldObj != nil {
+		oldRaw, err := json.Marshal(oldObj)
+		g.Expect(err).NotTo(HaveOccurred())
+		req.Operation = admissionv1.Update
+		req.OldObject = runtime.RawExtension{Raw: oldRaw}
+	}
+
+	return req
+}
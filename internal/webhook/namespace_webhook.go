@@ -6,8 +6,10 @@ import (
 	"fmt"
 	"net/http"
 
+	"github.com/dana-team/env-route-ns-mutator/internal/config"
 	"github.com/dana-team/env-route-ns-mutator/internal/utils"
 	"github.com/go-logr/logr"
+	admissionv1 "k8s.io/api/admission/v1"
 	corev1 "k8s.io/api/core/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
@@ -20,7 +22,13 @@ type NamespaceMutator struct {
 	Client  client.Client
 }
 
-const DefaultSchedulerAnnotation = "scheduler.alpha.kubernetes.io/defaultTolerations"
+const (
+	DefaultSchedulerAnnotation = "scheduler.alpha.kubernetes.io/defaultTolerations"
+	NodeSelectorAnnotation     = "openshift.io/node-selector"
+	// PinnedEnvironmentAnnotation marks the environment label of a Namespace as manually
+	// pinned, so it is never changed or removed to match the defaultTolerations annotation.
+	PinnedEnvironmentAnnotation = "environment.dana.io/pinned"
+)
 
 // +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch;create;update;patch
 
@@ -30,35 +38,185 @@ func (r *NamespaceMutator) Handle(ctx context.Context, req admission.Request) ad
 	logger := log.FromContext(ctx).WithName("Namespace").WithValues("name", req.Name)
 	logger.Info("webhook request received")
 
+	cfg, err := config.Current()
+	if err != nil {
+		logger.Error(err, "failed to get configuration")
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+
+	if response, excluded := excludedByName(logger, cfg.Exclusions, req.Name); excluded {
+		return response
+	}
+
 	namespace := corev1.Namespace{}
 	if err := r.Decoder.Decode(req, &namespace); err != nil {
 		logger.Error(err, "failed to decode namespace object")
 		return admission.Errored(http.StatusInternalServerError, err)
 	}
 
-	environments := utils.GetEnvironments()
-	r.handleInner(logger, &namespace, environments)
+	var oldNamespace *corev1.Namespace
+	if req.Operation == admissionv1.Update {
+		oldNamespace = &corev1.Namespace{}
+		if err := r.Decoder.DecodeRaw(req.OldObject, oldNamespace); err != nil {
+			logger.Error(err, "failed to decode old namespace object")
+			return admission.Errored(http.StatusInternalServerError, err)
+		}
+	}
+
+	// On UPDATE, the label exclusions are taken from the old Namespace, so that a requester
+	// cannot exclude a Namespace in the same request that edits its enforced keys.
+	exclusionLabels := namespace.Labels
+	if oldNamespace != nil {
+		exclusionLabels = oldNamespace.Labels
+	}
+	if response, excluded := excludedByLabels(logger, cfg.Exclusions, exclusionLabels, exclusionLabels); excluded {
+		return response
+	}
+
+	environments, settings := cfg.Environments, cfg.EnvironmentSettings
+	_, authorized := utils.MatchEnvironmentAdmin(cfg.EnvironmentAdminGroups, req.UserInfo)
+	warnings := r.handleInner(logger, &namespace, oldNamespace, environments, settings, authorized)
 
 	marshaledNamespace, err := json.Marshal(namespace)
 	if err != nil {
 		admission.Errored(http.StatusInternalServerError, err)
 	}
 
-	return admission.PatchResponseFromRaw(req.Object.Raw, marshaledNamespace)
+	return withEnvironmentChain(admission.PatchResponseFromRaw(req.Object.Raw, marshaledNamespace).WithWarnings(warnings...), namespace.Labels, environments, settings)
 }
 
-// handleInner implements the main mutating logic. It modifies the labels of
-// a Namespace based on environment data.
-func (r *NamespaceMutator) handleInner(logger logr.Logger, namespace *corev1.Namespace, environments []string) {
+// handleInner implements the main mutating logic. It keeps the environment label and the
+// scheduling annotations of a Namespace in sync, and applies the bundle of the environment.
+// The defaultTolerations annotation sets the label; a label without the annotation generates
+// the annotation. When both disagree the annotation wins and a warning is returned. On
+// UPDATE, oldNamespace is the Namespace before the update, and the label is removed when
+// the annotation is changed to tolerate no environment or is removed, unless it is pinned.
+// The annotations are generated from the label only when the requester is authorized to
+// set the label, so that the label cannot authorize itself.
+func (r *NamespaceMutator) handleInner(logger logr.Logger, namespace, oldNamespace *corev1.Namespace, environments []string, settings map[string]utils.EnvironmentSettings, authorized bool) []string {
+	var warnings []string
+
+	labelEnv, hasLabelEnv := utils.NamespaceEnvironment(namespace.GetLabels(), environments)
+	pinned := hasLabelEnv && namespace.GetAnnotations()[PinnedEnvironmentAnnotation] == "true"
+	oldValue, hadAnnotation := "", false
+	if oldNamespace != nil {
+		oldValue, hadAnnotation = oldNamespace.GetAnnotations()[DefaultSchedulerAnnotation]
+	}
+
 	if value, ok := namespace.Annotations[DefaultSchedulerAnnotation]; ok {
-		for _, env := range environments {
-			toleration := fmt.Sprintf("[{\"operator\": \"Exists\", \"effect\": \"NoSchedule\", \"key\": %s}]", env)
+		tolerations, err := utils.ParseTolerations(value)
+		if err != nil {
+			logger.Error(err, "failed to parse default tolerations")
+			return append(warnings, fmt.Sprintf("annotation %s could not be parsed: %v", DefaultSchedulerAnnotation, err))
+		}
 
-			if value == toleration {
-				labels := utils.AppendLabels(namespace.GetLabels(), map[string]string{utils.Key: env})
-				namespace.SetLabels(labels)
-				logger.Info("successfully updated labels")
+		env, ok := tolerationsEnvironment(tolerations, environments, settings)
+		switch {
+		case ok && hasLabelEnv && labelEnv != env && pinned:
+			warnings = append(warnings, fmt.Sprintf("pinned label %s=%s conflicts with annotation %s, which tolerates environment %q", utils.Key, labelEnv, DefaultSchedulerAnnotation, env))
+		case ok:
+			if hasLabelEnv && labelEnv != env {
+				warnings = append(warnings, fmt.Sprintf("label %s=%s conflicts with annotation %s, which tolerates environment %q; the label is set to %q", utils.Key, labelEnv, DefaultSchedulerAnnotation, env, env))
 			}
+			labels := utils.AppendLabels(namespace.GetLabels(), map[string]string{utils.Key: env})
+			namespace.SetLabels(labels)
+			logger.Info("successfully updated labels")
+		case hasLabelEnv && !pinned && oldNamespace != nil && oldValue != value:
+			r.removeLabel(logger, namespace)
+		case hasLabelEnv:
+			warnings = append(warnings, fmt.Sprintf("annotation %s does not tolerate environment %q of label %s", DefaultSchedulerAnnotation, labelEnv, utils.Key))
+		}
+	} else if hasLabelEnv && !pinned && hadAnnotation {
+		r.removeLabel(logger, namespace)
+	} else if hasLabelEnv && !authorized {
+		warnings = append(warnings, fmt.Sprintf("annotation %s is not generated for label %s=%s, since the requester is not an environment admin", DefaultSchedulerAnnotation, utils.Key, labelEnv))
+	} else if hasLabelEnv {
+		value, err := utils.FormatTolerations(utils.EnvironmentTolerations(labelEnv, settings[labelEnv]))
+		if err != nil {
+			logger.Error(err, "failed to format default tolerations")
+			return append(warnings, fmt.Sprintf("annotation %s could not be generated: %v", DefaultSchedulerAnnotation, err))
 		}
+		namespace.SetAnnotations(utils.AppendLabels(namespace.GetAnnotations(), map[string]string{DefaultSchedulerAnnotation: value}))
+		logger.Info("successfully generated default tolerations")
+	}
+
+	env, ok := utils.NamespaceEnvironment(namespace.GetLabels(), environments)
+	if !ok {
+		return warnings
+	}
+
+	warnings = append(warnings, r.setNodeSelector(logger, namespace, env, settings[env].NodeSelector)...)
+	r.applyBundle(logger, namespace, settings[env].Namespace)
+
+	return warnings
+}
+
+// removeLabel removes the stale environment label of a Namespace whose defaultTolerations
+// annotation no longer tolerates an environment.
+func (r *NamespaceMutator) removeLabel(logger logr.Logger, namespace *corev1.Namespace) {
+	labels := namespace.GetLabels()
+	delete(labels, utils.Key)
+	namespace.SetLabels(labels)
+	logger.Info("successfully removed stale environment label")
+}
+
+// tolerationsEnvironment returns the first environment whose tolerations are all in the
+// tolerations of a Namespace.
+func tolerationsEnvironment(tolerations []corev1.Toleration, environments []string, settings map[string]utils.EnvironmentSettings) (string, bool) {
+	for _, env := range environments {
+		if len(env) > 0 && utils.ContainsTolerations(tolerations, utils.EnvironmentTolerations(env, settings[env])) {
+			return env, true
+		}
+	}
+
+	return "", false
+}
+
+// setNodeSelector generates the node selector annotation of the environment on a Namespace
+// which does not set it, and returns a warning when the Namespace sets a different one.
+func (r *NamespaceMutator) setNodeSelector(logger logr.Logger, namespace *corev1.Namespace, env, nodeSelector string) []string {
+	if len(nodeSelector) == 0 {
+		return nil
+	}
+
+	value, ok := namespace.GetAnnotations()[NodeSelectorAnnotation]
+	if !ok {
+		namespace.SetAnnotations(utils.AppendLabels(namespace.GetAnnotations(), map[string]string{NodeSelectorAnnotation: nodeSelector}))
+		logger.Info("successfully generated node selector")
+		return nil
+	}
+
+	if value != nodeSelector {
+		return []string{fmt.Sprintf("annotation %s=%s conflicts with the node selector %q of environment %q", NodeSelectorAnnotation, value, nodeSelector, env)}
+	}
+
+	return nil
+}
+
+// applyBundle merges the labels and annotations of an environment bundle into the
+// Namespace. Keys which are already set keep their value, unless they are enforced.
+func (r *NamespaceMutator) applyBundle(logger logr.Logger, namespace *corev1.Namespace, bundle *utils.NamespaceBundle) {
+	if bundle == nil {
+		return
+	}
+
+	enforcedLabels, enforcedAnnotations := map[string]string{}, map[string]string{}
+	for _, key := range bundle.Enforced {
+		if value, ok := bundle.Labels[key]; ok {
+			enforcedLabels[key] = value
+		}
+		if value, ok := bundle.Annotations[key]; ok {
+			enforcedAnnotations[key] = value
+		}
+	}
+
+	if len(bundle.Labels) > 0 {
+		labels := utils.AppendDefaults(namespace.GetLabels(), bundle.Labels)
+		namespace.SetLabels(utils.AppendLabels(labels, enforcedLabels))
+	}
+	if len(bundle.Annotations) > 0 {
+		annotations := utils.AppendDefaults(namespace.GetAnnotations(), bundle.Annotations)
+		namespace.SetAnnotations(utils.AppendLabels(annotations, enforcedAnnotations))
 	}
+	logger.Info("successfully applied environment bundle")
 }
@@ -1,6 +1,7 @@
 package webhook
 
 import (
+	"context"
 	"fmt"
 	"testing"
 
@@ -8,9 +9,11 @@ import (
 	corev1 "k8s.io/api/core/v1"
 
 	. "github.com/onsi/gomega"
+	authenticationv1 "k8s.io/api/authentication/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	testclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 )
@@ -46,7 +49,7 @@ func TestNamespaceMutator(t *testing.T) {
 				},
 			}
 
-			rm.handleInner(logger, namespace, environments)
+			rm.handleInner(logger, namespace, nil, environments, nil, true)
 
 			if tc.mutated {
 				g.Expect(namespace.GetLabels()[utils.Key]).To(Equal(tc.env))
@@ -56,3 +59,207 @@ func TestNamespaceMutator(t *testing.T) {
 		})
 	}
 }
+
+func TestNamespaceMutatorBundle(t *testing.T) {
+	logger := ctrl.Log.WithName("webhook")
+
+	const (
+		enforceLabel    = "pod-security.kubernetes.io/enforce"
+		tierLabel       = "network-policy.dana.io/tier"
+		costCenterLabel = "cost-center"
+	)
+
+	environments := []string{env1, env2}
+	settings := map[string]utils.EnvironmentSettings{
+		env1: {Namespace: &utils.NamespaceBundle{
+			Labels:      map[string]string{enforceLabel: "restricted", tierLabel: "internal", costCenterLabel: "1234"},
+			Annotations: map[string]string{NodeSelectorAnnotation: "environment=env1"},
+			Enforced:    []string{enforceLabel, NodeSelectorAnnotation},
+		}},
+	}
+
+	env1Tolerations := fmt.Sprintf(`[{"key":"%s","operator":"Exists","effect":"NoSchedule"}]`, env1)
+	env2Tolerations := fmt.Sprintf(`[{"key":"%s","operator":"Exists","effect":"NoSchedule"}]`, env2)
+
+	tests := []struct {
+		name                string
+		labels              map[string]string
+		annotations         map[string]string
+		expectedLabels      map[string]string
+		expectedAnnotations map[string]string
+	}{
+		{name: "namespaceInEnvironment", labels: map[string]string{utils.Key: env1}, expectedLabels: map[string]string{utils.Key: env1, enforceLabel: "restricted", tierLabel: "internal", costCenterLabel: "1234"}, expectedAnnotations: map[string]string{DefaultSchedulerAnnotation: env1Tolerations, NodeSelectorAnnotation: "environment=env1"}},
+		{name: "namespaceWithUserValues", labels: map[string]string{utils.Key: env1, costCenterLabel: "5678"}, annotations: map[string]string{"example.com/owner": "team"}, expectedLabels: map[string]string{utils.Key: env1, enforceLabel: "restricted", tierLabel: "internal", costCenterLabel: "5678"}, expectedAnnotations: map[string]string{DefaultSchedulerAnnotation: env1Tolerations, NodeSelectorAnnotation: "environment=env1", "example.com/owner": "team"}},
+		{name: "namespaceWithEditedEnforcedKeys", labels: map[string]string{utils.Key: env1, enforceLabel: "privileged"}, annotations: map[string]string{NodeSelectorAnnotation: ""}, expectedLabels: map[string]string{utils.Key: env1, enforceLabel: "restricted", tierLabel: "internal", costCenterLabel: "1234"}, expectedAnnotations: map[string]string{DefaultSchedulerAnnotation: env1Tolerations, NodeSelectorAnnotation: "environment=env1"}},
+		{name: "namespaceInEnvironmentWithoutBundle", labels: map[string]string{utils.Key: env2}, expectedLabels: map[string]string{utils.Key: env2}, expectedAnnotations: map[string]string{DefaultSchedulerAnnotation: env2Tolerations}},
+		{name: "namespaceWithoutEnvironment", labels: map[string]string{costCenterLabel: "5678"}, expectedLabels: map[string]string{costCenterLabel: "5678"}},
+	}
+
+	client := testclient.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			rm := NamespaceMutator{Decoder: admission.NewDecoder(scheme.Scheme), Client: client}
+			namespace := &corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{Name: tc.name, Labels: tc.labels, Annotations: tc.annotations},
+			}
+
+			rm.handleInner(logger, namespace, nil, environments, settings, true)
+
+			g.Expect(namespace.GetLabels()).To(Equal(tc.expectedLabels))
+			g.Expect(namespace.GetAnnotations()).To(Equal(tc.expectedAnnotations))
+		})
+	}
+}
+
+func TestNamespaceMutatorScheduling(t *testing.T) {
+	logger := ctrl.Log.WithName("webhook")
+
+	const env1NodeSelector = "node-role.kubernetes.io/env1="
+
+	environments := []string{env1, env2}
+	settings := map[string]utils.EnvironmentSettings{
+		env1: {NodeSelector: env1NodeSelector},
+	}
+
+	env1Tolerations := fmt.Sprintf(`[{"key":"%s","operator":"Exists","effect":"NoSchedule"}]`, env1)
+	env2Tolerations := fmt.Sprintf(`[{"key":"%s","operator":"Exists","effect":"NoSchedule"}]`, env2)
+	legacyEnv2Tolerations := fmt.Sprintf("[{\"operator\": \"Exists\", \"effect\": \"NoSchedule\", \"key\": %s}]", env2)
+
+	tests := []struct {
+		name                string
+		labels              map[string]string
+		annotations         map[string]string
+		expectedLabel       string
+		expectedAnnotations map[string]string
+		warned              bool
+		unauthorized        bool
+	}{
+		{name: "labelWithoutAnnotations", labels: map[string]string{utils.Key: env1}, expectedLabel: env1, expectedAnnotations: map[string]string{DefaultSchedulerAnnotation: env1Tolerations, NodeSelectorAnnotation: env1NodeSelector}},
+		{name: "labelWithoutNodeSelectorSetting", labels: map[string]string{utils.Key: env2}, expectedLabel: env2, expectedAnnotations: map[string]string{DefaultSchedulerAnnotation: env2Tolerations}},
+		{name: "jsonAnnotationWithoutLabel", annotations: map[string]string{DefaultSchedulerAnnotation: env1Tolerations}, expectedLabel: env1, expectedAnnotations: map[string]string{DefaultSchedulerAnnotation: env1Tolerations, NodeSelectorAnnotation: env1NodeSelector}},
+		{name: "matchingLabelAndAnnotations", labels: map[string]string{utils.Key: env1}, annotations: map[string]string{DefaultSchedulerAnnotation: env1Tolerations, NodeSelectorAnnotation: env1NodeSelector}, expectedLabel: env1, expectedAnnotations: map[string]string{DefaultSchedulerAnnotation: env1Tolerations, NodeSelectorAnnotation: env1NodeSelector}},
+		{name: "conflictingLabelAndTolerations", labels: map[string]string{utils.Key: env1}, annotations: map[string]string{DefaultSchedulerAnnotation: legacyEnv2Tolerations}, expectedLabel: env2, expectedAnnotations: map[string]string{DefaultSchedulerAnnotation: legacyEnv2Tolerations}, warned: true},
+		{name: "conflictingNodeSelector", labels: map[string]string{utils.Key: env1}, annotations: map[string]string{NodeSelectorAnnotation: "node-role.kubernetes.io/worker="}, expectedLabel: env1, expectedAnnotations: map[string]string{DefaultSchedulerAnnotation: env1Tolerations, NodeSelectorAnnotation: "node-role.kubernetes.io/worker="}, warned: true},
+		{name: "labelWithOtherTolerations", labels: map[string]string{utils.Key: env1}, annotations: map[string]string{DefaultSchedulerAnnotation: `[{"key":"gpu","operator":"Exists"}]`}, expectedLabel: env1, expectedAnnotations: map[string]string{DefaultSchedulerAnnotation: `[{"key":"gpu","operator":"Exists"}]`, NodeSelectorAnnotation: env1NodeSelector}, warned: true},
+		{name: "invalidAnnotation", annotations: map[string]string{DefaultSchedulerAnnotation: "not-tolerations"}, expectedLabel: "", expectedAnnotations: map[string]string{DefaultSchedulerAnnotation: "not-tolerations"}, warned: true},
+		{name: "labelByUnauthorizedRequester", labels: map[string]string{utils.Key: env2}, expectedLabel: env2, expectedAnnotations: nil, warned: true, unauthorized: true},
+		{name: "namespaceWithoutEnvironment", expectedLabel: "", expectedAnnotations: nil},
+	}
+
+	client := testclient.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			rm := NamespaceMutator{Decoder: admission.NewDecoder(scheme.Scheme), Client: client}
+			namespace := &corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{Name: tc.name, Labels: tc.labels, Annotations: tc.annotations},
+			}
+
+			warnings := rm.handleInner(logger, namespace, nil, environments, settings, !tc.unauthorized)
+
+			g.Expect(namespace.GetLabels()[utils.Key]).To(Equal(tc.expectedLabel))
+			g.Expect(namespace.GetAnnotations()).To(Equal(tc.expectedAnnotations))
+			if tc.warned {
+				g.Expect(warnings).NotTo(BeEmpty())
+			} else {
+				g.Expect(warnings).To(BeEmpty())
+			}
+		})
+	}
+}
+
+func TestNamespaceMutatorUpdate(t *testing.T) {
+	logger := ctrl.Log.WithName("webhook")
+
+	environments := []string{env1, env2}
+
+	env1Tolerations := fmt.Sprintf(`[{"key":"%s","operator":"Exists","effect":"NoSchedule"}]`, env1)
+	env2Tolerations := fmt.Sprintf(`[{"key":"%s","operator":"Exists","effect":"NoSchedule"}]`, env2)
+	otherTolerations := `[{"key":"gpu","operator":"Exists"}]`
+	pinned := map[string]string{PinnedEnvironmentAnnotation: "true"}
+
+	tests := []struct {
+		name           string
+		oldAnnotations map[string]string
+		labels         map[string]string
+		annotations    map[string]string
+		expectedLabel  string
+	}{
+		{name: "annotationAdded", oldAnnotations: nil, annotations: map[string]string{DefaultSchedulerAnnotation: env1Tolerations}, expectedLabel: env1},
+		{name: "annotationChanged", oldAnnotations: map[string]string{DefaultSchedulerAnnotation: env1Tolerations}, labels: map[string]string{utils.Key: env1}, annotations: map[string]string{DefaultSchedulerAnnotation: env2Tolerations}, expectedLabel: env2},
+		{name: "annotationChangedToOtherTolerations", oldAnnotations: map[string]string{DefaultSchedulerAnnotation: env1Tolerations}, labels: map[string]string{utils.Key: env1}, annotations: map[string]string{DefaultSchedulerAnnotation: otherTolerations}, expectedLabel: ""},
+		{name: "annotationRemoved", oldAnnotations: map[string]string{DefaultSchedulerAnnotation: env1Tolerations}, labels: map[string]string{utils.Key: env1}, annotations: map[string]string{}, expectedLabel: ""},
+		{name: "annotationUnchanged", oldAnnotations: map[string]string{DefaultSchedulerAnnotation: otherTolerations}, labels: map[string]string{utils.Key: env1}, annotations: map[string]string{DefaultSchedulerAnnotation: otherTolerations}, expectedLabel: env1},
+		{name: "labelAddedWithoutAnnotation", oldAnnotations: nil, labels: map[string]string{utils.Key: env1}, annotations: nil, expectedLabel: env1},
+		{name: "pinnedAnnotationChanged", oldAnnotations: map[string]string{DefaultSchedulerAnnotation: env1Tolerations, PinnedEnvironmentAnnotation: "true"}, labels: map[string]string{utils.Key: env1}, annotations: utils.AppendLabels(map[string]string{DefaultSchedulerAnnotation: env2Tolerations}, pinned), expectedLabel: env1},
+		{name: "pinnedAnnotationRemoved", oldAnnotations: map[string]string{DefaultSchedulerAnnotation: env1Tolerations, PinnedEnvironmentAnnotation: "true"}, labels: map[string]string{utils.Key: env1}, annotations: utils.AppendLabels(map[string]string{}, pinned), expectedLabel: env1},
+	}
+
+	client := testclient.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			rm := NamespaceMutator{Decoder: admission.NewDecoder(scheme.Scheme), Client: client}
+			oldNamespace := &corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{Name: tc.name, Labels: tc.labels, Annotations: tc.oldAnnotations},
+			}
+			namespace := &corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{Name: tc.name, Labels: tc.labels, Annotations: tc.annotations},
+			}
+
+			rm.handleInner(logger, namespace, oldNamespace, environments, nil, true)
+
+			g.Expect(namespace.GetLabels()[utils.Key]).To(Equal(tc.expectedLabel))
+		})
+	}
+}
+
+func TestNamespaceMutatorExclusions(t *testing.T) {
+	const (
+		excludedLabel = "tier"
+		enforceLabel  = "pod-security.kubernetes.io/enforce"
+	)
+
+	t.Setenv(utils.Env, fmt.Sprintf("%s,%s", env1, env2))
+	t.Setenv(utils.EnvironmentSettingsEnv, fmt.Sprintf("{%s: {namespace: {labels: {%s: restricted}, enforced: [%s]}}}", env1, enforceLabel, enforceLabel))
+	t.Setenv(utils.ExcludedNamespaceLabelsEnv, excludedLabel+" in (platform)")
+
+	admin := authenticationv1.UserInfo{Username: "admin", Groups: []string{"system:masters"}}
+	edited := map[string]string{utils.Key: env1, enforceLabel: "privileged", excludedLabel: "platform"}
+
+	tests := []struct {
+		name      string
+		oldLabels map[string]string
+		labels    map[string]string
+		create    bool
+		mutated   bool
+	}{
+		{name: "createWithExcludedLabel", create: true, labels: edited, mutated: false},
+		{name: "addExcludedLabelWithEnforcedKey", oldLabels: map[string]string{utils.Key: env1, enforceLabel: "restricted"}, labels: edited, mutated: true},
+		{name: "alreadyExcluded", oldLabels: map[string]string{utils.Key: env1, enforceLabel: "restricted", excludedLabel: "platform"}, labels: edited, mutated: false},
+		{name: "removeExcludedLabel", oldLabels: map[string]string{utils.Key: env1, excludedLabel: "platform"}, labels: map[string]string{utils.Key: env1}, mutated: false},
+	}
+
+	k8sClient := testclient.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			rm := NamespaceMutator{Decoder: admission.NewDecoder(scheme.Scheme), Client: k8sClient}
+			namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: tc.name, Labels: tc.labels}}
+			var oldNamespace client.Object
+			if !tc.create {
+				oldNamespace = &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: tc.name, Labels: tc.oldLabels}}
+			}
+
+			response := rm.Handle(context.Background(), admissionRequest(g, namespace, oldNamespace, admin))
+
+			g.Expect(response.Allowed).To(BeTrue())
+			g.Expect(len(response.Patches) > 0).To(Equal(tc.mutated))
+		})
+	}
+}
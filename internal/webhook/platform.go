@@ -0,0 +1,25 @@
+package webhook
+
+import (
+	"github.com/dana-team/env-route-ns-mutator/internal/utils"
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const platformRuleAuditAnnotation = "platform-rule"
+
+// platformOwned returns an allowed response if the object or the requester matches
+// one of the platform rules. The matched rule is recorded in an audit annotation.
+func platformOwned(logger logr.Logger, rules []utils.PlatformRule, obj metav1.Object, req admission.Request) (admission.Response, bool) {
+	rule, ok := utils.MatchPlatformRule(rules, obj, req.UserInfo)
+	if !ok {
+		return admission.Response{}, false
+	}
+
+	logger.Info("Object is managed by a platform controller, skipping mutation", "rule", rule.Name)
+	response := admission.Allowed("managed by a platform controller")
+	response.AuditAnnotations = map[string]string{platformRuleAuditAnnotation: rule.Name}
+
+	return response, true
+}
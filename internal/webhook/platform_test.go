@@ -0,0 +1,65 @@
+package webhook
+
+import (
+	"testing"
+
+	"github.com/dana-team/env-route-ns-mutator/internal/utils"
+
+	. "github.com/onsi/gomega"
+	routev1 "github.com/openshift/api/route/v1"
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func TestPlatformRules(t *testing.T) {
+	logger := ctrl.Log.WithName("webhook")
+
+	tests := []struct {
+		name       string
+		configured string
+		owners     []metav1.OwnerReference
+		managers   []string
+		userInfo   authenticationv1.UserInfo
+		rule       string
+	}{
+		{name: "userRoute", userInfo: authenticationv1.UserInfo{Username: "developer", Groups: []string{"system:authenticated"}}, rule: ""},
+		{name: "openshiftServiceAccount", userInfo: authenticationv1.UserInfo{Username: "system:serviceaccount:openshift-console-operator:console-operator"}, rule: "openshift-service-accounts"},
+		{name: "ingressToRouteController", userInfo: authenticationv1.UserInfo{Username: "system:serviceaccount:openshift-infra:ingress-to-route-controller"}, rule: "openshift-service-accounts"},
+		{name: "knativeServiceAccount", userInfo: authenticationv1.UserInfo{Username: "system:serviceaccount:knative-serving:controller"}, rule: "knative-serving"},
+		{name: "forgedManager", managers: []string{"openshift-controller-manager"}, userInfo: authenticationv1.UserInfo{Username: "developer"}, rule: ""},
+		{name: "forgedKnativeIngressOwner", owners: []metav1.OwnerReference{{APIVersion: "networking.internal.knative.dev/v1alpha1", Kind: "Ingress", Name: "test"}}, userInfo: authenticationv1.UserInfo{Username: "developer"}, rule: ""},
+		{name: "unrelatedOwner", owners: []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "Deployment", Name: "test"}}, rule: ""},
+		{name: "configuredGroup", configured: "[{name: platform-team, group: platform-admins}]", userInfo: authenticationv1.UserInfo{Username: "admin", Groups: []string{"platform-admins"}}, rule: "platform-team"},
+		{name: "configuredOwnerAndManager", configured: "[{name: argo, ownerKind: Application, manager: argocd-*, trustClientFields: true}]", owners: []metav1.OwnerReference{{APIVersion: "argoproj.io/v1alpha1", Kind: "Application", Name: "test"}}, managers: []string{"argocd-controller"}, rule: "argo"},
+		{name: "configuredOwnerWithoutManager", configured: "[{name: argo, ownerKind: Application, manager: argocd-*, trustClientFields: true}]", owners: []metav1.OwnerReference{{APIVersion: "argoproj.io/v1alpha1", Kind: "Application", Name: "test"}}, rule: ""},
+		{name: "configuredManagerOfServiceAccount", configured: "[{name: argo, manager: argocd-*, username: \"system:serviceaccount:argocd:*\"}]", managers: []string{"argocd-controller"}, userInfo: authenticationv1.UserInfo{Username: "system:serviceaccount:argocd:argocd-application-controller"}, rule: "argo"},
+		{name: "configuredManagerOfAnotherUser", configured: "[{name: argo, manager: argocd-*, username: \"system:serviceaccount:argocd:*\"}]", managers: []string{"argocd-controller"}, userInfo: authenticationv1.UserInfo{Username: "developer"}, rule: ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			t.Setenv(utils.PlatformRulesEnv, tc.configured)
+			rules, err := utils.GetPlatformRules()
+			g.Expect(err).NotTo(HaveOccurred())
+
+			route := &routev1.Route{ObjectMeta: metav1.ObjectMeta{Name: tc.name, Namespace: testNamespace, OwnerReferences: tc.owners}}
+			for _, manager := range tc.managers {
+				route.ManagedFields = append(route.ManagedFields, metav1.ManagedFieldsEntry{Manager: manager})
+			}
+
+			req := admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{UserInfo: tc.userInfo}}
+			response, owned := platformOwned(logger, rules, route, req)
+
+			g.Expect(owned).To(Equal(len(tc.rule) > 0))
+			if owned {
+				g.Expect(response.Allowed).To(BeTrue())
+				g.Expect(response.AuditAnnotations).To(HaveKeyWithValue(platformRuleAuditAnnotation, tc.rule))
+			}
+		})
+	}
+}
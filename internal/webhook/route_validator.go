@@ -0,0 +1,112 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/dana-team/env-route-ns-mutator/internal/config"
+	"github.com/dana-team/env-route-ns-mutator/internal/utils"
+	"github.com/go-logr/logr"
+	routev1 "github.com/openshift/api/route/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// RouteValidator is the struct used to validate Routes
+type RouteValidator struct {
+	Decoder admission.Decoder
+	Client  client.Client
+}
+
+// +kubebuilder:webhook:path=/validate-v1-route,mutating=false,failurePolicy=ignore,sideEffects=None,groups=route.openshift.io,resources=routes,verbs=create;update,versions=v1,name=vroute.dana.io,admissionReviewVersions=v1;v1beta1
+
+func (r *RouteValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	logger := log.FromContext(ctx).WithName("RouteValidator").WithValues("name", req.Name)
+	logger.Info("webhook request received")
+
+	cfg, err := config.Current()
+	if err != nil {
+		logger.Error(err, "failed to get configuration")
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+
+	if response, excluded := excludedByName(logger, cfg.Exclusions, req.Namespace); excluded {
+		return response
+	}
+
+	route := routev1.Route{}
+	if err := r.Decoder.Decode(req, &route); err != nil {
+		logger.Error(err, "failed to decode route object")
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+
+	if owner, ok := ingressOwner(&route); ok {
+		response, err := verifyIngressRoute(ctx, logger, r.Client, &route, owner)
+		if err != nil {
+			logger.Error(err, "failed to get owner ingress object")
+			return admission.Errored(http.StatusInternalServerError, err)
+		}
+		return response
+	}
+
+	if response, owned := platformOwned(logger, cfg.PlatformRules, &route, req); owned {
+		return response
+	}
+
+	namespace := corev1.Namespace{}
+	if err := r.Client.Get(ctx, types.NamespacedName{Name: req.Namespace}, &namespace); err != nil {
+		logger.Error(err, "failed to get namespace object")
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+
+	if response, excluded := excludedByLabels(logger, cfg.Exclusions, namespace.Labels, route.Labels); excluded {
+		return response
+	}
+
+	if response, ok := bypassed(logger, cfg.Bypass, namespace.Labels, req.UserInfo); ok {
+		return response
+	}
+
+	environments, settings := cfg.Environments, cfg.EnvironmentSettings
+	return r.handleInner(logger, &route, environments, settings, namespace.Labels)
+}
+
+// handleInner implements the main validating logic. It rejects Routes which change the
+// enforced annotations of the environment, or which weaken its TLS policy, by not setting
+// TLS or by allowing insecure traffic. The mutator only runs on creation, so this is what
+// keeps the policy of the environment on updates.
+func (r *RouteValidator) handleInner(logger logr.Logger, route *routev1.Route, environments []string, settings map[string]utils.EnvironmentSettings, labels map[string]string) admission.Response {
+	env, ok := utils.NamespaceEnvironment(labels, environments)
+	if !ok {
+		return admission.Allowed("")
+	}
+
+	for _, key := range settings[env].EnforcedRouteAnnotations {
+		if value := settings[env].RouteAnnotations[key]; route.Annotations[key] != value {
+			logger.Info("Denying route which changes an enforced annotation", "annotation", key)
+			return admission.Denied(fmt.Sprintf("routes in environment %q must set annotation %q to %q", env, key, value))
+		}
+	}
+
+	routeTLS := settings[env].RouteTLS
+	if routeTLS == nil || !routeTLS.Required {
+		return admission.Allowed("")
+	}
+
+	if route.Spec.TLS == nil {
+		logger.Info("Denying route without tls")
+		return admission.Denied(fmt.Sprintf("routes in environment %q must set spec.tls", env))
+	}
+
+	if route.Spec.TLS.InsecureEdgeTerminationPolicy == routev1.InsecureEdgeTerminationPolicyAllow {
+		logger.Info("Denying route which allows insecure traffic")
+		return admission.Denied(fmt.Sprintf("routes in environment %q must not set insecureEdgeTerminationPolicy to %q", env, routev1.InsecureEdgeTerminationPolicyAllow))
+	}
+
+	return admission.Allowed("")
+}
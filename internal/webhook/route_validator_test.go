@@ -0,0 +1,106 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/dana-team/env-route-ns-mutator/internal/utils"
+
+	. "github.com/onsi/gomega"
+	routev1 "github.com/openshift/api/route/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	testclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func TestRouteValidator(t *testing.T) {
+	logger := ctrl.Log.WithName("webhook")
+
+	environments := []string{env1, env2}
+	settings := map[string]utils.EnvironmentSettings{
+		env1: {RouteTLS: &utils.RouteTLS{Termination: routev1.TLSTerminationEdge, InsecureEdgeTerminationPolicy: routev1.InsecureEdgeTerminationPolicyRedirect, Required: true}},
+		env2: {RouteTLS: &utils.RouteTLS{Termination: routev1.TLSTerminationEdge}},
+	}
+
+	tests := []struct {
+		name     string
+		tls      *routev1.TLSConfig
+		nsLabels map[string]string
+		allowed  bool
+	}{
+		{name: "edgeRoute", tls: &routev1.TLSConfig{Termination: routev1.TLSTerminationEdge, InsecureEdgeTerminationPolicy: routev1.InsecureEdgeTerminationPolicyRedirect}, nsLabels: map[string]string{utils.Key: env1}, allowed: true},
+		{name: "edgeRouteAllowingInsecure", tls: &routev1.TLSConfig{Termination: routev1.TLSTerminationEdge, InsecureEdgeTerminationPolicy: routev1.InsecureEdgeTerminationPolicyAllow}, nsLabels: map[string]string{utils.Key: env1}, allowed: false},
+		{name: "passthroughRoute", tls: &routev1.TLSConfig{Termination: routev1.TLSTerminationPassthrough, InsecureEdgeTerminationPolicy: routev1.InsecureEdgeTerminationPolicyNone}, nsLabels: map[string]string{utils.Key: env1}, allowed: true},
+		{name: "reencryptRoute", tls: &routev1.TLSConfig{Termination: routev1.TLSTerminationReencrypt}, nsLabels: map[string]string{utils.Key: env1}, allowed: true},
+		{name: "reencryptRouteAllowingInsecure", tls: &routev1.TLSConfig{Termination: routev1.TLSTerminationReencrypt, InsecureEdgeTerminationPolicy: routev1.InsecureEdgeTerminationPolicyAllow}, nsLabels: map[string]string{utils.Key: env1}, allowed: false},
+		{name: "routeWithoutTLS", nsLabels: map[string]string{utils.Key: env1}, allowed: false},
+		{name: "routeWithoutTLSNotRequired", nsLabels: map[string]string{utils.Key: env2}, allowed: true},
+		{name: "routeWithoutLabels", nsLabels: map[string]string{}, allowed: true},
+	}
+
+	client := testclient.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			rv := RouteValidator{Decoder: admission.NewDecoder(scheme.Scheme), Client: client}
+			route := &routev1.Route{
+				ObjectMeta: metav1.ObjectMeta{Name: tc.name, Namespace: testNamespace},
+				Spec:       routev1.RouteSpec{TLS: tc.tls},
+			}
+
+			response := rv.handleInner(logger, route, environments, settings, tc.nsLabels)
+
+			g.Expect(response.Allowed).To(Equal(tc.allowed))
+		})
+	}
+}
+
+func TestRouteValidatorEnforcedAnnotations(t *testing.T) {
+	const hstsAnnotation = "haproxy.router.openshift.io/hsts_header"
+	const hsts = "max-age=31536000"
+
+	t.Setenv(utils.Env, fmt.Sprintf("%s,%s", env1, env2))
+	t.Setenv(utils.EnvironmentSettingsEnv, fmt.Sprintf("{%s: {routeAnnotations: {%s: %s}, enforcedRouteAnnotations: [%s]}}", env1, hstsAnnotation, hsts, hstsAnnotation))
+
+	developer := authenticationv1.UserInfo{Username: "developer"}
+	enforced := map[string]string{hstsAnnotation: hsts}
+
+	tests := []struct {
+		name           string
+		oldAnnotations map[string]string
+		annotations    map[string]string
+		create         bool
+		allowed        bool
+	}{
+		{name: "createWithEnforcedAnnotation", create: true, annotations: enforced, allowed: true},
+		{name: "updateKeepingAnnotation", oldAnnotations: enforced, annotations: map[string]string{hstsAnnotation: hsts, "other": "value"}, allowed: true},
+		{name: "updateChangingAnnotation", oldAnnotations: enforced, annotations: map[string]string{hstsAnnotation: "max-age=0"}, allowed: false},
+		{name: "updateRemovingAnnotation", oldAnnotations: enforced, annotations: map[string]string{}, allowed: false},
+	}
+
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: testNamespace, Labels: map[string]string{utils.Key: env1}}}
+	k8sClient := testclient.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(namespace).Build()
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			rv := RouteValidator{Decoder: admission.NewDecoder(scheme.Scheme), Client: k8sClient}
+			route := &routev1.Route{ObjectMeta: metav1.ObjectMeta{Name: tc.name, Namespace: testNamespace, Annotations: tc.annotations}}
+			var oldRoute client.Object
+			if !tc.create {
+				oldRoute = &routev1.Route{ObjectMeta: metav1.ObjectMeta{Name: tc.name, Namespace: testNamespace, Annotations: tc.oldAnnotations}}
+			}
+
+			response := rv.Handle(context.Background(), admissionRequest(g, route, oldRoute, developer))
+
+			g.Expect(response.Allowed).To(Equal(tc.allowed))
+		})
+	}
+}
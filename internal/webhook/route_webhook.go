@@ -3,10 +3,12 @@ package webhook
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
+	"github.com/dana-team/env-route-ns-mutator/internal/config"
 	"github.com/dana-team/env-route-ns-mutator/internal/utils"
 	"github.com/go-logr/logr"
 	routev1 "github.com/openshift/api/route/v1"
@@ -31,47 +33,170 @@ func (r *RouteMutator) Handle(ctx context.Context, req admission.Request) admiss
 	logger := log.FromContext(ctx).WithName("Route").WithValues("name", req.Name)
 	logger.Info("webhook request received")
 
+	cfg, err := config.Current()
+	if err != nil {
+		logger.Error(err, "failed to get configuration")
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+
+	if response, excluded := excludedByName(logger, cfg.Exclusions, req.Namespace); excluded {
+		return response
+	}
+
 	route := routev1.Route{}
 	if err := r.Decoder.Decode(req, &route); err != nil {
 		logger.Error(err, "failed to decode route object")
 		return admission.Errored(http.StatusInternalServerError, err)
 	}
 
+	if owner, ok := ingressOwner(&route); ok {
+		response, err := verifyIngressRoute(ctx, logger, r.Client, &route, owner)
+		if err != nil {
+			logger.Error(err, "failed to get owner ingress object")
+			return admission.Errored(http.StatusInternalServerError, err)
+		}
+		return response
+	}
+
+	if response, owned := platformOwned(logger, cfg.PlatformRules, &route, req); owned {
+		return response
+	}
+
 	namespace := corev1.Namespace{}
 	if err := r.Client.Get(ctx, types.NamespacedName{Name: req.Namespace}, &namespace); err != nil {
 		logger.Error(err, "failed to get namespace object")
 		return admission.Errored(http.StatusInternalServerError, err)
 	}
 
+	if response, excluded := excludedByLabels(logger, cfg.Exclusions, namespace.Labels, route.Labels); excluded {
+		return response
+	}
+
+	if response, ok := bypassed(logger, cfg.Bypass, namespace.Labels, req.UserInfo); ok {
+		return response
+	}
+
 	clusterIngress, err := utils.GetClusterIngressDomain(ctx, r.Client)
 	if err != nil {
 		logger.Error(err, "failed to get cluster ingress")
 		return admission.Errored(http.StatusInternalServerError, err)
 	}
 
-	environments := utils.GetEnvironments()
-	r.handleInner(logger, &route, clusterIngress, environments, namespace.ObjectMeta.Labels)
+	environments, settings := cfg.Environments, cfg.EnvironmentSettings
+	if err := r.handleInner(logger, &route, clusterIngress, environments, settings, namespace.ObjectMeta.Labels); err != nil {
+		logger.Error(err, "failed to mutate route")
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	var warnings []string
+	if env, ok := utils.NamespaceEnvironment(namespace.Labels, environments); ok && len(route.Spec.Host) > 0 {
+		domain, err := utils.EnvironmentHostDomain(env, settings[env], namespace.Labels)
+		if err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		envDomain := fmt.Sprintf("%s-%s", domain, clusterIngress)
+		host, warning, err := resolveHost(ctx, logger, r.Client, route.Spec.Host, route.Namespace, envDomain, settings[env].HostCollision)
+		if err != nil {
+			return hostErrorResponse(logger, err)
+		}
+		route.Spec.Host = host
+		warnings = appendWarning(warnings, warning)
+
+		if usesRouterCertificate(&route) {
+			warning, err := checkCoverage(ctx, logger, r.Client, host, envDomain, settings[env])
+			if err != nil {
+				return hostErrorResponse(logger, err)
+			}
+			warnings = appendWarning(warnings, warning)
+		}
+	}
 
 	marshaledRoute, err := json.Marshal(route)
 	if err != nil {
 		admission.Errored(http.StatusInternalServerError, err)
 	}
 
-	return admission.PatchResponseFromRaw(req.Object.Raw, marshaledRoute)
+	return withEnvironmentChain(admission.PatchResponseFromRaw(req.Object.Raw, marshaledRoute).WithWarnings(warnings...), namespace.Labels, environments, settings)
 }
 
 // handleInner implements the main mutating logic. It modifies the host of an OpenShift Route
-// based on environment data and cluster ingress information.
-func (r *RouteMutator) handleInner(logger logr.Logger, route *routev1.Route, clusterIngress string, environments []string, labels map[string]string) {
-	if utils.CheckBypass(labels) {
-		logger.Info("Bypassing mutation")
+// based on environment data and cluster ingress information, and applies the defaults
+// of the environment. It fails when the Namespace lacks a hostname dimension label.
+func (r *RouteMutator) handleInner(logger logr.Logger, route *routev1.Route, clusterIngress string, environments []string, settings map[string]utils.EnvironmentSettings, labels map[string]string) error {
+	env, ok := utils.NamespaceEnvironment(labels, environments)
+	if !ok {
+		return nil
+	}
+	domain, err := utils.EnvironmentHostDomain(env, settings[env], labels)
+	if err != nil {
+		return err
+	}
+
+	if len(route.Spec.Host) == 0 && len(route.Spec.Subdomain) > 0 {
+		r.handleSubdomain(logger, route, clusterIngress, domain, settings[env].SubdomainPolicy)
+	} else {
+		route.Spec.Host = utils.ModifyHostname(logger, route.Name, route.Namespace, route.Spec.Host, domain, clusterIngress)
+	}
+	r.setIssuerAnnotations(logger, route, settings[env])
+	r.setRouteAnnotations(logger, route, settings[env])
+	r.setTLSDefaults(logger, route, settings[env])
+
+	return nil
+}
+
+// handleSubdomain handles Routes which set spec.subdomain instead of spec.host, according
+// to the subdomain policy of the environment.
+func (r *RouteMutator) handleSubdomain(logger logr.Logger, route *routev1.Route, clusterIngress, domain string, policy utils.SubdomainPolicy) {
+	if policy == utils.SubdomainPolicyShard {
+		logger.Info("Subdomain is left for the environment router shard, remains unchanged", "subdomain", route.Spec.Subdomain)
 		return
 	}
-	for _, env := range environments {
-		if labels[utils.Key] == env {
-			routeHost := utils.ModifyHostname(logger, route.Name, route.Namespace, route.Spec.Host, env, clusterIngress)
-			route.Spec.Host = routeHost
-			break
+
+	subdomainHost := fmt.Sprintf("%s.%s", route.Spec.Subdomain, clusterIngress)
+	route.Spec.Host = utils.ModifyHostname(logger, route.Name, route.Namespace, subdomainHost, domain, clusterIngress)
+	route.Spec.Subdomain = ""
+	logger.Info("Subdomain converted to an environment host", "hostname", route.Spec.Host)
+}
+
+// setIssuerAnnotations adds the cert-manager issuer annotations of the environment
+// which are not already set on the Route.
+func (r *RouteMutator) setIssuerAnnotations(logger logr.Logger, route *routev1.Route, settings utils.EnvironmentSettings) {
+	if len(settings.IssuerAnnotations) == 0 {
+		return
+	}
+
+	route.SetAnnotations(utils.AppendDefaults(route.GetAnnotations(), settings.IssuerAnnotations))
+	logger.Info("successfully updated issuer annotations")
+}
+
+// setRouteAnnotations adds the router annotations of the environment to the Route. The
+// value set on the Route wins, unless the environment enforces the annotation.
+func (r *RouteMutator) setRouteAnnotations(logger logr.Logger, route *routev1.Route, settings utils.EnvironmentSettings) {
+	if len(settings.RouteAnnotations) == 0 {
+		return
+	}
+
+	annotations := utils.AppendDefaults(route.GetAnnotations(), settings.RouteAnnotations)
+	for _, key := range settings.EnforcedRouteAnnotations {
+		if value, ok := annotations[key]; ok && value != settings.RouteAnnotations[key] {
+			logger.Info("Overriding enforced route annotation", "annotation", key, "value", value)
 		}
+		annotations[key] = settings.RouteAnnotations[key]
+	}
+	route.SetAnnotations(annotations)
+	logger.Info("successfully updated route annotations")
+}
+
+// setTLSDefaults sets the default TLS configuration of the environment on Routes
+// which do not set spec.tls.
+func (r *RouteMutator) setTLSDefaults(logger logr.Logger, route *routev1.Route, settings utils.EnvironmentSettings) {
+	if route.Spec.TLS != nil || settings.RouteTLS == nil || len(settings.RouteTLS.Termination) == 0 {
+		return
+	}
+
+	route.Spec.TLS = &routev1.TLSConfig{
+		Termination:                   settings.RouteTLS.Termination,
+		InsecureEdgeTerminationPolicy: settings.RouteTLS.InsecureEdgeTerminationPolicy,
 	}
+	logger.Info("successfully set default tls", "termination", route.Spec.TLS.Termination)
 }
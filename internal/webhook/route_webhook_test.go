@@ -16,10 +16,13 @@ import (
 )
 
 const (
-	env1                 = "env1"
-	env2                 = "env2"
-	testNamespace        = "test-ns"
-	clusterIngressDomain = "apps.ocp-test.os-test.com"
+	env1                    = "env1"
+	env2                    = "env2"
+	testNamespace           = "test-ns"
+	clusterIngressDomain    = "apps.ocp-test.os-test.com"
+	clusterIssuerAnnotation = "cert-manager.io/cluster-issuer"
+	issuerNameAnnotation    = "cert-manager.io/issuer-name"
+	env1Issuer              = "env1-issuer"
 )
 
 func TestRouteMutator(t *testing.T) {
@@ -41,7 +44,6 @@ func TestRouteMutator(t *testing.T) {
 		{name: "routeWithCustomNameDefaultDomain", namespace: testNamespace, hostname: "test2", customDomain: "", defaultDomain: true, nsLabels: map[string]string{utils.Key: env1}, mutated: true},
 		{name: "routeWithNoCustomNameNoDomain", namespace: testNamespace, hostname: "", customDomain: "", defaultDomain: false, nsLabels: map[string]string{utils.Key: env1}, mutated: true},
 		{name: "routeWithoutLabels", namespace: testNamespace, hostname: "test5", customDomain: "", defaultDomain: true, nsLabels: map[string]string{}, mutated: false},
-		{name: "routeWithBypassLabel", namespace: testNamespace, hostname: "test6", customDomain: "", defaultDomain: true, nsLabels: map[string]string{bypassLabel: "true", utils.Key: env1}, mutated: false},
 		{name: "routeWithInvalidBypassLabel", namespace: testNamespace, hostname: "test7", customDomain: "", defaultDomain: true, nsLabels: map[string]string{bypassLabel: "false", utils.Key: env1}, mutated: true},
 		{name: "routeWithMutatedHostname", namespace: testNamespace, hostname: "test8", customDomain: fmt.Sprintf("%s-%s", env1, clusterIngressDomain), defaultDomain: false, nsLabels: map[string]string{utils.Key: env1}, mutated: false},
 	}
@@ -66,7 +68,7 @@ func TestRouteMutator(t *testing.T) {
 				Spec:       routev1.RouteSpec{Host: routeHost},
 			}
 
-			rm.handleInner(logger, route, clusterIngressDomain, environments, tc.nsLabels)
+			g.Expect(rm.handleInner(logger, route, clusterIngressDomain, environments, nil, tc.nsLabels)).To(Succeed())
 
 			mutatedHost := ""
 			if tc.mutated {
@@ -85,3 +87,173 @@ func TestRouteMutator(t *testing.T) {
 		})
 	}
 }
+
+func TestRouteMutatorSubdomain(t *testing.T) {
+	logger := ctrl.Log.WithName("webhook")
+
+	environments := []string{env1, env2}
+	settings := map[string]utils.EnvironmentSettings{
+		env1: {SubdomainPolicy: utils.SubdomainPolicyRewrite},
+		env2: {SubdomainPolicy: utils.SubdomainPolicyShard},
+	}
+
+	tests := []struct {
+		name              string
+		host              string
+		subdomain         string
+		nsLabels          map[string]string
+		expectedHost      string
+		expectedSubdomain string
+	}{
+		{name: "subdomainRewrite", subdomain: "test1", nsLabels: map[string]string{utils.Key: env1}, expectedHost: fmt.Sprintf("test1.%s-%s", env1, clusterIngressDomain), expectedSubdomain: ""},
+		{name: "subdomainShard", subdomain: "test2", nsLabels: map[string]string{utils.Key: env2}, expectedHost: "", expectedSubdomain: "test2"},
+		{name: "subdomainWithHost", host: fmt.Sprintf("test3.%s", clusterIngressDomain), subdomain: "test3", nsLabels: map[string]string{utils.Key: env2}, expectedHost: fmt.Sprintf("test3.%s-%s", env2, clusterIngressDomain), expectedSubdomain: "test3"},
+		{name: "subdomainWithoutLabels", subdomain: "test4", nsLabels: map[string]string{}, expectedHost: "", expectedSubdomain: "test4"},
+	}
+
+	client := testclient.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			rm := RouteMutator{Decoder: admission.NewDecoder(scheme.Scheme), Client: client}
+			route := &routev1.Route{
+				ObjectMeta: metav1.ObjectMeta{Name: tc.name, Namespace: testNamespace},
+				Spec:       routev1.RouteSpec{Host: tc.host, Subdomain: tc.subdomain},
+			}
+
+			g.Expect(rm.handleInner(logger, route, clusterIngressDomain, environments, settings, tc.nsLabels)).To(Succeed())
+
+			g.Expect(route.Spec.Host).To(Equal(tc.expectedHost))
+			g.Expect(route.Spec.Subdomain).To(Equal(tc.expectedSubdomain))
+		})
+	}
+}
+
+func TestRouteMutatorIssuerAnnotations(t *testing.T) {
+	logger := ctrl.Log.WithName("webhook")
+
+	environments := []string{env1, env2}
+	settings := map[string]utils.EnvironmentSettings{
+		env1: {IssuerAnnotations: map[string]string{clusterIssuerAnnotation: env1Issuer, issuerNameAnnotation: env1Issuer}},
+	}
+
+	tests := []struct {
+		name                string
+		annotations         map[string]string
+		nsLabels            map[string]string
+		expectedAnnotations map[string]string
+	}{
+		{name: "routeWithoutAnnotations", nsLabels: map[string]string{utils.Key: env1}, expectedAnnotations: map[string]string{clusterIssuerAnnotation: env1Issuer, issuerNameAnnotation: env1Issuer}},
+		{name: "routeWithUserAnnotation", annotations: map[string]string{clusterIssuerAnnotation: "user-issuer"}, nsLabels: map[string]string{utils.Key: env1}, expectedAnnotations: map[string]string{clusterIssuerAnnotation: "user-issuer", issuerNameAnnotation: env1Issuer}},
+		{name: "routeInEnvironmentWithoutSettings", nsLabels: map[string]string{utils.Key: env2}, expectedAnnotations: nil},
+		{name: "routeWithoutLabels", nsLabels: map[string]string{}, expectedAnnotations: nil},
+	}
+
+	client := testclient.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			rm := RouteMutator{Decoder: admission.NewDecoder(scheme.Scheme), Client: client}
+			route := &routev1.Route{
+				ObjectMeta: metav1.ObjectMeta{Name: tc.name, Namespace: testNamespace, Annotations: tc.annotations},
+				Spec:       routev1.RouteSpec{Host: fmt.Sprintf("test.%s", clusterIngressDomain)},
+			}
+
+			g.Expect(rm.handleInner(logger, route, clusterIngressDomain, environments, settings, tc.nsLabels)).To(Succeed())
+
+			g.Expect(route.GetAnnotations()).To(Equal(tc.expectedAnnotations))
+		})
+	}
+}
+
+func TestRouteMutatorRouteAnnotations(t *testing.T) {
+	logger := ctrl.Log.WithName("webhook")
+
+	const (
+		timeoutAnnotation   = "haproxy.router.openshift.io/timeout"
+		hstsAnnotation      = "haproxy.router.openshift.io/hsts_header"
+		whitelistAnnotation = "haproxy.router.openshift.io/ip_whitelist"
+		hstsHeader          = "max-age=31536000;includeSubDomains;preload"
+	)
+
+	environments := []string{env1, env2}
+	settings := map[string]utils.EnvironmentSettings{
+		env1: {
+			RouteAnnotations:         map[string]string{timeoutAnnotation: "30s", hstsAnnotation: hstsHeader},
+			EnforcedRouteAnnotations: []string{hstsAnnotation},
+		},
+	}
+
+	tests := []struct {
+		name                string
+		annotations         map[string]string
+		nsLabels            map[string]string
+		expectedAnnotations map[string]string
+	}{
+		{name: "routeWithoutAnnotations", nsLabels: map[string]string{utils.Key: env1}, expectedAnnotations: map[string]string{timeoutAnnotation: "30s", hstsAnnotation: hstsHeader}},
+		{name: "routeWithUserAnnotation", annotations: map[string]string{timeoutAnnotation: "5m"}, nsLabels: map[string]string{utils.Key: env1}, expectedAnnotations: map[string]string{timeoutAnnotation: "5m", hstsAnnotation: hstsHeader}},
+		{name: "routeWithEnforcedAnnotation", annotations: map[string]string{hstsAnnotation: "max-age=0"}, nsLabels: map[string]string{utils.Key: env1}, expectedAnnotations: map[string]string{timeoutAnnotation: "30s", hstsAnnotation: hstsHeader}},
+		{name: "routeWithOtherAnnotation", annotations: map[string]string{whitelistAnnotation: "10.0.0.0/8"}, nsLabels: map[string]string{utils.Key: env1}, expectedAnnotations: map[string]string{timeoutAnnotation: "30s", hstsAnnotation: hstsHeader, whitelistAnnotation: "10.0.0.0/8"}},
+		{name: "routeInEnvironmentWithoutSettings", annotations: map[string]string{timeoutAnnotation: "5m"}, nsLabels: map[string]string{utils.Key: env2}, expectedAnnotations: map[string]string{timeoutAnnotation: "5m"}},
+		{name: "routeWithoutLabels", nsLabels: map[string]string{}, expectedAnnotations: nil},
+	}
+
+	client := testclient.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			rm := RouteMutator{Decoder: admission.NewDecoder(scheme.Scheme), Client: client}
+			route := &routev1.Route{
+				ObjectMeta: metav1.ObjectMeta{Name: tc.name, Namespace: testNamespace, Annotations: tc.annotations},
+				Spec:       routev1.RouteSpec{Host: fmt.Sprintf("test.%s", clusterIngressDomain)},
+			}
+
+			g.Expect(rm.handleInner(logger, route, clusterIngressDomain, environments, settings, tc.nsLabels)).To(Succeed())
+
+			g.Expect(route.GetAnnotations()).To(Equal(tc.expectedAnnotations))
+		})
+	}
+}
+
+func TestRouteMutatorTLS(t *testing.T) {
+	logger := ctrl.Log.WithName("webhook")
+
+	environments := []string{env1, env2}
+	settings := map[string]utils.EnvironmentSettings{
+		env1: {RouteTLS: &utils.RouteTLS{Termination: routev1.TLSTerminationEdge, InsecureEdgeTerminationPolicy: routev1.InsecureEdgeTerminationPolicyRedirect, Required: true}},
+	}
+
+	tests := []struct {
+		name        string
+		tls         *routev1.TLSConfig
+		nsLabels    map[string]string
+		expectedTLS *routev1.TLSConfig
+	}{
+		{name: "routeWithoutTLS", nsLabels: map[string]string{utils.Key: env1}, expectedTLS: &routev1.TLSConfig{Termination: routev1.TLSTerminationEdge, InsecureEdgeTerminationPolicy: routev1.InsecureEdgeTerminationPolicyRedirect}},
+		{name: "routeWithEdgeTLS", tls: &routev1.TLSConfig{Termination: routev1.TLSTerminationEdge}, nsLabels: map[string]string{utils.Key: env1}, expectedTLS: &routev1.TLSConfig{Termination: routev1.TLSTerminationEdge}},
+		{name: "routeWithPassthroughTLS", tls: &routev1.TLSConfig{Termination: routev1.TLSTerminationPassthrough}, nsLabels: map[string]string{utils.Key: env1}, expectedTLS: &routev1.TLSConfig{Termination: routev1.TLSTerminationPassthrough}},
+		{name: "routeWithReencryptTLS", tls: &routev1.TLSConfig{Termination: routev1.TLSTerminationReencrypt}, nsLabels: map[string]string{utils.Key: env1}, expectedTLS: &routev1.TLSConfig{Termination: routev1.TLSTerminationReencrypt}},
+		{name: "routeInEnvironmentWithoutSettings", nsLabels: map[string]string{utils.Key: env2}, expectedTLS: nil},
+		{name: "routeWithoutLabels", nsLabels: map[string]string{}, expectedTLS: nil},
+	}
+
+	client := testclient.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			rm := RouteMutator{Decoder: admission.NewDecoder(scheme.Scheme), Client: client}
+			route := &routev1.Route{
+				ObjectMeta: metav1.ObjectMeta{Name: tc.name, Namespace: testNamespace},
+				Spec:       routev1.RouteSpec{Host: fmt.Sprintf("test.%s", clusterIngressDomain), TLS: tc.tls},
+			}
+
+			g.Expect(rm.handleInner(logger, route, clusterIngressDomain, environments, settings, tc.nsLabels)).To(Succeed())
+
+			g.Expect(route.Spec.TLS).To(Equal(tc.expectedTLS))
+		})
+	}
+}
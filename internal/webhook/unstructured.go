@@ -0,0 +1,80 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/dana-team/env-route-ns-mutator/internal/config"
+	"github.com/dana-team/env-route-ns-mutator/internal/utils"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// unstructuredHandler is implemented by the mutators of optional resources, which are
+// handled as unstructured objects so that their CRDs are not a hard dependency.
+type unstructuredHandler interface {
+	handleInner(ctx context.Context, logger logr.Logger, obj *unstructured.Unstructured, clusterIngress string, environments []string, settings map[string]utils.EnvironmentSettings, labels map[string]string) error
+}
+
+// handleUnstructured implements the flow shared by the mutators of unstructured objects:
+// it applies the exclusions and platform rules, retrieves the namespace, cluster ingress
+// and environment data, and calls the handleInner of the mutator.
+func handleUnstructured(ctx context.Context, logger logr.Logger, req admission.Request, decoder admission.Decoder, k8sClient client.Client, handler unstructuredHandler) admission.Response {
+	cfg, err := config.Current()
+	if err != nil {
+		logger.Error(err, "failed to get configuration")
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+
+	if response, excluded := excludedByName(logger, cfg.Exclusions, req.Namespace); excluded {
+		return response
+	}
+
+	obj := unstructured.Unstructured{}
+	if err := decoder.Decode(req, &obj); err != nil {
+		logger.Error(err, "failed to decode object")
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+
+	if response, owned := platformOwned(logger, cfg.PlatformRules, &obj, req); owned {
+		return response
+	}
+
+	namespace := corev1.Namespace{}
+	if err := k8sClient.Get(ctx, types.NamespacedName{Name: req.Namespace}, &namespace); err != nil {
+		logger.Error(err, "failed to get namespace object")
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+
+	if response, excluded := excludedByLabels(logger, cfg.Exclusions, namespace.Labels, obj.GetLabels()); excluded {
+		return response
+	}
+
+	if response, ok := bypassed(logger, cfg.Bypass, namespace.Labels, req.UserInfo); ok {
+		return response
+	}
+
+	clusterIngress, err := utils.GetClusterIngressDomain(ctx, k8sClient)
+	if err != nil {
+		logger.Error(err, "failed to get cluster ingress")
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+
+	environments, settings := cfg.Environments, cfg.EnvironmentSettings
+	if err := handler.handleInner(ctx, logger, &obj, clusterIngress, environments, settings, namespace.Labels); err != nil {
+		logger.Error(err, "failed to mutate object")
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	marshaledObj, err := json.Marshal(obj.Object)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+
+	return withEnvironmentChain(admission.PatchResponseFromRaw(req.Object.Raw, marshaledObj), namespace.Labels, environments, settings)
+}